@@ -0,0 +1,342 @@
+package odam
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AGGREGATION_OBJECT Alias to int
+type AGGREGATION_OBJECT int
+
+const (
+	// AGGREGATION_OBJECT_LINE Statistics are collected for virtual line
+	AGGREGATION_OBJECT_LINE = AGGREGATION_OBJECT(iota + 1)
+	// AGGREGATION_OBJECT_POLYGON Statistics are collected for virtual polygon
+	AGGREGATION_OBJECT_POLYGON
+)
+
+// String returns text representation of aggregated object type
+func (ao AGGREGATION_OBJECT) String() string {
+	switch ao {
+	case AGGREGATION_OBJECT_LINE:
+		return "line"
+	case AGGREGATION_OBJECT_POLYGON:
+		return "polygon"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(ao))
+	}
+}
+
+// MarshalJSON Returns text representation of aggregated object type as JSON string
+func (ao AGGREGATION_OBJECT) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ao.String())
+}
+
+// AggregationSummary Statistics for single virtual line or virtual polygon over single time bucket
+type AggregationSummary struct {
+	ObjectType AGGREGATION_OBJECT `json:"object_type"`
+	// Identifier of line or polygon
+	ObjectID int64 `json:"object_id"`
+	// Duration of bucket (in seconds)
+	IntervalSeconds int64 `json:"interval_seconds"`
+	// Bounds of bucket (Unix UTC)
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	// Bucket has not been finished (e.g. video stream has ended)
+	Partial bool `json:"partial"`
+	// Number of events for each class: crossings for line, entries for polygon
+	Counts map[string]uint64 `json:"counts"`
+	// Mean speed of counted objects. Only objects with estimated speed are taken into account
+	MeanSpeed float64 `json:"mean_speed"`
+	// Number of objects with estimated speed
+	SpeedSamples uint64 `json:"speed_samples"`
+	// Fraction of frames [0; 1] when there was at least one object on line or inside of polygon
+	Occupancy float64 `json:"occupancy"`
+	// Mean number of objects on line or inside of polygon
+	MeanObjects float64 `json:"mean_objects"`
+	// Maximum number of objects on line or inside of polygon
+	MaxObjects int `json:"max_objects"`
+}
+
+// aggregationBucket Accumulated statistics for single time bucket
+type aggregationBucket struct {
+	start          time.Time
+	counts         map[string]uint64
+	speedSum       float64
+	speedSamples   uint64
+	frames         uint64
+	occupiedFrames uint64
+	objectsSum     uint64
+	maxObjects     int
+	// Has anything been registered in bucket?
+	touched bool
+}
+
+// newAggregationBucket Constructor for aggregationBucket
+func newAggregationBucket(start time.Time) *aggregationBucket {
+	return &aggregationBucket{
+		start:  start,
+		counts: make(map[string]uint64),
+	}
+}
+
+// summary Prepares AggregationSummary for bucket
+func (bucket *aggregationBucket) summary(target *aggregationTarget, interval time.Duration, end time.Time, partial bool) AggregationSummary {
+	summary := AggregationSummary{
+		ObjectType:      target.objectType,
+		ObjectID:        target.objectID,
+		IntervalSeconds: int64(interval / time.Second),
+		Start:           bucket.start.UTC().Unix(),
+		End:             end.UTC().Unix(),
+		Partial:         partial,
+		Counts:          make(map[string]uint64, len(bucket.counts)),
+		SpeedSamples:    bucket.speedSamples,
+		MaxObjects:      bucket.maxObjects,
+	}
+	for className, count := range bucket.counts {
+		summary.Counts[className] = count
+	}
+	if bucket.speedSamples > 0 {
+		summary.MeanSpeed = bucket.speedSum / float64(bucket.speedSamples)
+	}
+	if bucket.frames > 0 {
+		summary.Occupancy = float64(bucket.occupiedFrames) / float64(bucket.frames)
+		summary.MeanObjects = float64(bucket.objectsSum) / float64(bucket.frames)
+	}
+	return summary
+}
+
+// aggregationTarget Statistics of single virtual line or virtual polygon: one bucket for each interval
+type aggregationTarget struct {
+	objectType AGGREGATION_OBJECT
+	objectID   int64
+	buckets    []*aggregationBucket
+}
+
+// Aggregator Keeps statistics for each virtual line and virtual polygon over time buckets of configured durations
+// Time is driven by timestamps of frames (see ref. Advance()), so buckets are aligned to video time.
+// Summary of every finished bucket is passed to handler (see ref. SetHandler()). There is no handler by default, so summaries are dropped
+type Aggregator struct {
+	sync.Mutex
+	intervals []time.Duration
+	targets   []*aggregationTarget
+	lines     map[int64]*aggregationTarget
+	polygons  map[int64]*aggregationTarget
+	handler   func(summary AggregationSummary)
+	lastTime  time.Time
+}
+
+// NewAggregator Constructor for Aggregator
+//
+// intervals - durations of time buckets
+// linesIDs - identifiers of virtual lines
+// polygonsIDs - identifiers of virtual polygons
+//
+func NewAggregator(intervals []time.Duration, linesIDs, polygonsIDs []int64) *Aggregator {
+	agg := Aggregator{
+		intervals: intervals,
+		targets:   make([]*aggregationTarget, 0, len(linesIDs)+len(polygonsIDs)),
+		lines:     make(map[int64]*aggregationTarget, len(linesIDs)),
+		polygons:  make(map[int64]*aggregationTarget, len(polygonsIDs)),
+	}
+	for _, id := range linesIDs {
+		if _, ok := agg.lines[id]; ok {
+			continue
+		}
+		agg.lines[id] = agg.addTarget(AGGREGATION_OBJECT_LINE, id)
+	}
+	for _, id := range polygonsIDs {
+		if _, ok := agg.polygons[id]; ok {
+			continue
+		}
+		agg.polygons[id] = agg.addTarget(AGGREGATION_OBJECT_POLYGON, id)
+	}
+	return &agg
+}
+
+// addTarget Registers new line or polygon
+func (agg *Aggregator) addTarget(objectType AGGREGATION_OBJECT, objectID int64) *aggregationTarget {
+	target := aggregationTarget{
+		objectType: objectType,
+		objectID:   objectID,
+		buckets:    make([]*aggregationBucket, len(agg.intervals)),
+	}
+	agg.targets = append(agg.targets, &target)
+	return &target
+}
+
+// SetHandler Sets function which is called for every finished bucket
+// Handler is called synchronously by tracker, so it should not block for long. Nil handler means that summaries are dropped
+func (agg *Aggregator) SetHandler(handler func(summary AggregationSummary)) {
+	agg.Lock()
+	defer agg.Unlock()
+	agg.handler = handler
+}
+
+// report Passes summary to handler if it is set
+func (agg *Aggregator) report(summary AggregationSummary) {
+	if agg.handler == nil {
+		return
+	}
+	agg.handler(summary)
+}
+
+// Advance Moves time of aggregator to given timestamp: finished buckets are passed to handler and new ones are started
+// It should be called for every frame before registering anything else for it
+// Notice: buckets without any data (e.g. there were no frames at all) are not reported
+func (agg *Aggregator) Advance(t time.Time) {
+	agg.Lock()
+	defer agg.Unlock()
+	for _, target := range agg.targets {
+		for i, interval := range agg.intervals {
+			start := t.Truncate(interval)
+			bucket := target.buckets[i]
+			if bucket != nil && bucket.start.Equal(start) {
+				continue
+			}
+			if bucket != nil && bucket.touched {
+				agg.report(bucket.summary(target, interval, bucket.start.Add(interval), false))
+			}
+			target.buckets[i] = newAggregationBucket(start)
+		}
+	}
+	agg.lastTime = t
+}
+
+// Flush Passes unfinished buckets to handler (e.g. when video stream has ended). Summaries are marked as partial
+func (agg *Aggregator) Flush() {
+	agg.Lock()
+	defer agg.Unlock()
+	for _, target := range agg.targets {
+		for i, interval := range agg.intervals {
+			bucket := target.buckets[i]
+			if bucket == nil || !bucket.touched {
+				continue
+			}
+			agg.report(bucket.summary(target, interval, agg.lastTime, true))
+			target.buckets[i] = nil
+		}
+	}
+}
+
+// RegisterLineCrossing Counts object which has crossed virtual line
+//
+// lineID - identifier of line
+// className - class of object
+// speed - estimated speed of object. Ignored if speedKnown is false
+// speedKnown - has speed been estimated
+//
+func (agg *Aggregator) RegisterLineCrossing(lineID int64, className string, speed float32, speedKnown bool) {
+	agg.Lock()
+	defer agg.Unlock()
+	agg.registerEvent(agg.lines[lineID], className, speed, speedKnown)
+}
+
+// RegisterPolygonEntry Counts object which has entered virtual polygon
+//
+// polygonID - identifier of polygon
+// className - class of object
+// speed - estimated speed of object. Ignored if speedKnown is false
+// speedKnown - has speed been estimated
+//
+func (agg *Aggregator) RegisterPolygonEntry(polygonID int64, className string, speed float32, speedKnown bool) {
+	agg.Lock()
+	defer agg.Unlock()
+	agg.registerEvent(agg.polygons[polygonID], className, speed, speedKnown)
+}
+
+// SampleLineOccupancy Registers number of objects which are on virtual line at current frame
+func (agg *Aggregator) SampleLineOccupancy(lineID int64, objectsNum int) {
+	agg.Lock()
+	defer agg.Unlock()
+	agg.sampleOccupancy(agg.lines[lineID], objectsNum)
+}
+
+// SamplePolygonOccupancy Registers number of objects which are inside of virtual polygon at current frame
+func (agg *Aggregator) SamplePolygonOccupancy(polygonID int64, objectsNum int) {
+	agg.Lock()
+	defer agg.Unlock()
+	agg.sampleOccupancy(agg.polygons[polygonID], objectsNum)
+}
+
+// registerEvent Updates counters for every bucket of target
+func (agg *Aggregator) registerEvent(target *aggregationTarget, className string, speed float32, speedKnown bool) {
+	if target == nil {
+		return
+	}
+	for _, bucket := range target.buckets {
+		if bucket == nil {
+			// Advance() has not been called yet
+			continue
+		}
+		bucket.touched = true
+		bucket.counts[className]++
+		if speedKnown {
+			bucket.speedSum += float64(speed)
+			bucket.speedSamples++
+		}
+	}
+}
+
+// sampleOccupancy Updates occupancy for every bucket of target
+func (agg *Aggregator) sampleOccupancy(target *aggregationTarget, objectsNum int) {
+	if target == nil {
+		return
+	}
+	for _, bucket := range target.buckets {
+		if bucket == nil {
+			continue
+		}
+		bucket.touched = true
+		bucket.frames++
+		if objectsNum > 0 {
+			bucket.occupiedFrames++
+			bucket.objectsSum += uint64(objectsNum)
+		}
+		if objectsNum > bucket.maxObjects {
+			bucket.maxObjects = objectsNum
+		}
+	}
+}
+
+// AggregationWriter Writes summaries to file as JSON lines (one summary per line)
+// Use Handle() as handler for Aggregator (see ref. Aggregator.SetHandler())
+type AggregationWriter struct {
+	sync.Mutex
+	file *os.File
+}
+
+// NewAggregationWriter Constructor for AggregationWriter. Summaries are appended to file if it exists already
+func NewAggregationWriter(fname string) (*AggregationWriter, error) {
+	file, err := os.OpenFile(fname, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't open file for aggregation summaries")
+	}
+	return &AggregationWriter{file: file}, nil
+}
+
+// Handle Writes single summary. Errors are printed, since handler can't return them
+func (aw *AggregationWriter) Handle(summary AggregationSummary) {
+	bytes, err := json.Marshal(summary)
+	if err != nil {
+		fmt.Printf("Can't marshal aggregation summary. Error: %s\n", err.Error())
+		return
+	}
+	aw.Lock()
+	defer aw.Unlock()
+	if _, err = aw.file.Write(append(bytes, '\n')); err != nil {
+		fmt.Printf("Can't write aggregation summary. Error: %s\n", err.Error())
+	}
+}
+
+// Close Closes underlying file
+func (aw *AggregationWriter) Close() error {
+	aw.Lock()
+	defer aw.Unlock()
+	return aw.file.Close()
+}
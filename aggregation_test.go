@@ -0,0 +1,148 @@
+package odam
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAggregatorBuckets(t *testing.T) {
+	agg := NewAggregator([]time.Duration{time.Minute, 5 * time.Minute}, []int64{1}, []int64{2})
+	summaries := []AggregationSummary{}
+	agg.SetHandler(func(summary AggregationSummary) {
+		summaries = append(summaries, summary)
+	})
+	baseTime := time.Unix(600, 0)
+	agg.Advance(baseTime)
+	agg.RegisterLineCrossing(1, "car", 40, true)
+	agg.SampleLineOccupancy(1, 1)
+	agg.RegisterPolygonEntry(2, "bus", 0, false)
+	agg.SamplePolygonOccupancy(2, 2)
+
+	agg.Advance(baseTime.Add(30 * time.Second))
+	agg.RegisterLineCrossing(1, "car", 60, true)
+	agg.RegisterLineCrossing(1, "truck", 0, false)
+	agg.SampleLineOccupancy(1, 0)
+	agg.SamplePolygonOccupancy(2, 1)
+	if len(summaries) != 0 {
+		t.Errorf("No buckets should be finished yet, but got %d summaries", len(summaries))
+	}
+
+	// One minute bucket is finished, five minutes bucket is not
+	agg.Advance(baseTime.Add(time.Minute))
+	if len(summaries) != 2 {
+		t.Errorf("Should be 2 summaries (line and polygon), but got %d", len(summaries))
+		return
+	}
+	line := summaries[0]
+	if line.ObjectType != AGGREGATION_OBJECT_LINE || line.ObjectID != 1 || line.IntervalSeconds != 60 {
+		t.Errorf("First summary should be for line #1 with interval 60s, but got %s #%d with interval %ds", line.ObjectType, line.ObjectID, line.IntervalSeconds)
+	}
+	if line.Start != 600 || line.End != 660 || line.Partial {
+		t.Errorf("Bucket should be [600; 660) and finished, but got [%d; %d) (partial = %t)", line.Start, line.End, line.Partial)
+	}
+	if line.Counts["car"] != 2 || line.Counts["truck"] != 1 {
+		t.Errorf("Line should count 2 cars and 1 truck, but got %v", line.Counts)
+	}
+	if line.SpeedSamples != 2 || math.Abs(line.MeanSpeed-50) > 1e-9 {
+		t.Errorf("Mean speed should be 50 (2 samples), but got %f (%d samples)", line.MeanSpeed, line.SpeedSamples)
+	}
+	if math.Abs(line.Occupancy-0.5) > 1e-9 || line.MaxObjects != 1 {
+		t.Errorf("Line occupancy should be 0.5 with maximum 1 object, but got %f and %d", line.Occupancy, line.MaxObjects)
+	}
+	polygon := summaries[1]
+	if polygon.ObjectType != AGGREGATION_OBJECT_POLYGON || polygon.Counts["bus"] != 1 || polygon.SpeedSamples != 0 {
+		t.Errorf("Second summary should be for polygon with 1 bus and no speed samples, but got %s with %v and %d samples", polygon.ObjectType, polygon.Counts, polygon.SpeedSamples)
+	}
+	if math.Abs(polygon.Occupancy-1.0) > 1e-9 || math.Abs(polygon.MeanObjects-1.5) > 1e-9 || polygon.MaxObjects != 2 {
+		t.Errorf("Polygon occupancy should be 1.0 with 1.5 objects in average and 2 at maximum, but got %f, %f and %d", polygon.Occupancy, polygon.MeanObjects, polygon.MaxObjects)
+	}
+
+	// Unfinished buckets are reported as partial
+	summaries = summaries[:0]
+	agg.Flush()
+	for _, summary := range summaries {
+		if !summary.Partial || summary.IntervalSeconds != 300 || summary.End != 660 {
+			t.Errorf("Flushed summary should be partial with interval 300s and end 660, but got %t, %ds and %d", summary.Partial, summary.IntervalSeconds, summary.End)
+		}
+	}
+	if len(summaries) != 2 {
+		t.Errorf("Should be 2 partial summaries, but got %d", len(summaries))
+	}
+}
+
+func TestAggregationSettings(t *testing.T) {
+	as := AggregationSettings{Enabled: true}
+	as.Prepare()
+	if len(as.IntervalsDurations) != 3 || as.IntervalsDurations[0] != time.Minute || as.IntervalsDurations[2] != 15*time.Minute {
+		t.Errorf("Default intervals should be [1m 5m 15m], but got %v", as.IntervalsDurations)
+	}
+	as = AggregationSettings{Enabled: true, Intervals: []int{300, -1, 60, 300}}
+	as.Prepare()
+	if len(as.Intervals) != 2 || as.Intervals[0] != 60 || as.Intervals[1] != 300 {
+		t.Errorf("Intervals should be [60 300], but got %v", as.Intervals)
+	}
+	as = AggregationSettings{Enabled: true, Intervals: []int{0}}
+	as.Prepare()
+	if as.Enabled {
+		t.Errorf("Aggregation should be disabled when there are no valid intervals")
+	}
+}
+
+func TestAggregationWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odam_aggregation")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "summaries.jsonl")
+	writer, err := NewAggregationWriter(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	agg := NewAggregator([]time.Duration{time.Minute}, []int64{1}, nil)
+	// There is no handler by default: summaries should be dropped silently
+	agg.Advance(time.Unix(0, 0))
+	agg.RegisterLineCrossing(1, "car", 0, false)
+	agg.Advance(time.Unix(60, 0))
+
+	agg.SetHandler(writer.Handle)
+	agg.RegisterLineCrossing(1, "bus", 0, false)
+	agg.Advance(time.Unix(120, 0))
+	agg.RegisterLineCrossing(1, "truck", 0, false)
+	agg.Flush()
+	if err = writer.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+	file, err := os.Open(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+	summaries := []map[string]interface{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		summary := map[string]interface{}{}
+		if err = json.Unmarshal(scanner.Bytes(), &summary); err != nil {
+			t.Errorf("Each line should be valid JSON: %s", err.Error())
+			return
+		}
+		summaries = append(summaries, summary)
+	}
+	if len(summaries) != 2 {
+		t.Errorf("2 summaries are expected (bucket with 'car' should be dropped), but got %d", len(summaries))
+		return
+	}
+	if summaries[0]["partial"] != false || summaries[1]["partial"] != true {
+		t.Errorf("Only flushed summary should be partial")
+	}
+}
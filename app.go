@@ -8,6 +8,7 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"sync"
 	"time"
 
 	blob "github.com/LdDl/gocv-blob/v2/blob"
@@ -19,15 +20,28 @@ import (
 
 // Application Main engine
 type Application struct {
-	neuralNetwork  *gocv.Net
-	layersNames    []string
+	detector       Detector
 	blobiesStorage *blob.Blobies
-	trackerType    TRACKER_TYPE
-	gisConverter   *SpatialConverter
+	// Guards blobiesStorage, since tracker stage and sinks work in different goroutines
+	blobiesMutex sync.Mutex
+	trackerType  TRACKER_TYPE
+	gisConverter *SpatialConverter
+	// Correction of lens distortion. Nil when it is not configured
+	undistorter *Undistorter
 
 	settings   *AppSettings
 	grpcConn   *grpc.ClientConn
 	grpcClient ServiceYOLOClient
+	// gRPC messages which are being sent at the moment
+	pendingSends sync.WaitGroup
+	// Statistics for virtual lines and polygons. Nil when aggregation is disabled
+	aggregator *Aggregator
+	// Output of aggregation summaries to file. Nil when it is not configured
+	aggregationWriter *AggregationWriter
+	// Export of finished tracks. Nil when export is disabled
+	trajectoryWriter *TrajectoryWriter
+
+	closeOnce sync.Once
 }
 
 // NewApp Constructor for Application
@@ -36,57 +50,165 @@ type Application struct {
 //
 func NewApp(settings *AppSettings) (*Application, error) {
 	/* Initialize neural network */
-	neuralNet := gocv.ReadNet(settings.NeuralNetworkSettings.DarknetWeights, settings.NeuralNetworkSettings.DarknetCFG)
-	yoloLayersIdx := neuralNet.GetUnconnectedOutLayers()
-	outLayerNames := make([]string, 0, 3)
-	for _, idx := range yoloLayersIdx {
-		layer := neuralNet.GetLayer(idx)
-		outLayerNames = append(outLayerNames, layer.GetName())
-	}
-	err := neuralNet.SetPreferableBackend(gocv.NetBackendCUDA)
+	detector, err := NewYOLODetector(&settings.NeuralNetworkSettings, &settings.InferenceSettings)
 	if err != nil {
-		return nil, errors.Wrap(err, "Can't set backend CUDA")
+		return nil, errors.Wrap(err, "Can't prepare YOLO detector")
 	}
-	err = neuralNet.SetPreferableTarget(gocv.NetTargetCUDA)
+	app, err := NewAppWithDetector(settings, detector)
 	if err != nil {
-		return nil, errors.Wrap(err, "Can't set target CUDA")
+		// Detector has been created here, so nobody else would free it
+		detector.Close()
+		return nil, err
+	}
+	return app, nil
+}
+
+// NewAppWithDetector Constructor for Application with custom objects detector
+// Detector is not closed when error is returned, so caller should free it
+//
+// settings - pointer to AppSettings object
+// detector - any implementation of Detector interface
+//
+func NewAppWithDetector(settings *AppSettings, detector Detector) (*Application, error) {
+	if detector == nil {
+		return nil, fmt.Errorf("Detector should be provided")
+	}
+	/* Initialize correction of lens distortion if needed */
+	var undistorter *Undistorter
+	if settings.VideoSettings.Undistortion != nil {
+		undistorter = NewUndistorter(settings.VideoSettings.Undistortion, settings.VideoSettings.Width, settings.VideoSettings.Height, settings.VideoSettings.ScaleX, settings.VideoSettings.ScaleY)
+	}
+	// Track points should be undistorted before spatial conversion only if frames are kept distorted
+	var pointsUndistorter *Undistorter
+	if undistorter != nil && settings.VideoSettings.Undistortion.GetModeType() == UNDISTORTION_MODE_TRACK_POINTS {
+		pointsUndistorter = undistorter
 	}
 	/* Initialize GIS converter (for speed estimation) if needed*/
-	// It just helps to figure out what does [Longitude; Latitude] pair correspond to certain pixel
-	spatialConverter := SpatialConverter{}
+	// It just helps to figure out what does [Longitude; Latitude] pair (or local metric coordinates) correspond to certain pixel
+	spatialConverter := NewIdentitySpatialConverter()
 	if settings.TrackerSettings.SpeedEstimationSettings.Enabled {
-		if len(settings.TrackerSettings.SpeedEstimationSettings.Mapper) != 4 {
-			fmt.Println("[WARNING] 'mapper' field in 'speed_estimation_settings' should contain exactly 4 elements. Disabling speed estimation feature...")
+		converter, err := NewSpatialConverter(&settings.TrackerSettings.SpeedEstimationSettings, pointsUndistorter)
+		if err != nil {
+			fmt.Printf("[WARNING] Can't prepare mapper in 'speed_estimation_settings': %s. Disabling speed estimation feature...\n", err.Error())
 			settings.TrackerSettings.SpeedEstimationSettings.Enabled = false
 		} else {
-			src := make([]gocv.Point2f, len(settings.TrackerSettings.SpeedEstimationSettings.Mapper))
-			dst := make([]gocv.Point2f, len(settings.TrackerSettings.SpeedEstimationSettings.Mapper))
-			for i := range settings.TrackerSettings.SpeedEstimationSettings.Mapper {
-				ptImage := settings.TrackerSettings.SpeedEstimationSettings.Mapper[i].ImageCoordinates
-				ptGIS := settings.TrackerSettings.SpeedEstimationSettings.Mapper[i].EPSG4326
-				src[i] = gocv.Point2f{X: ptImage[0], Y: ptImage[1]}
-				dst[i] = gocv.Point2f{X: ptGIS[0], Y: ptGIS[1]}
+			err = checkReprojectionErrors(converter, &settings.TrackerSettings.SpeedEstimationSettings)
+			if err != nil {
+				converter.Close()
+				if undistorter != nil {
+					undistorter.Close()
+				}
+				return nil, err
 			}
-			spatialConverter.transformMat, spatialConverter.Function = GetPerspectiveTransformer(src, dst)
+			spatialConverter = converter
 		}
 	}
-	return &Application{
-		neuralNetwork:  &neuralNet,
-		layersNames:    outLayerNames,
+	app := Application{
+		detector:       detector,
 		blobiesStorage: blob.NewBlobiesDefaults(),
 		trackerType:    settings.TrackerSettings.GetTrackerType(),
-		gisConverter:   &spatialConverter,
+		gisConverter:   spatialConverter,
+		undistorter:    undistorter,
 		settings:       settings,
-	}, nil
+	}
+	/* Initialize aggregation of events if needed */
+	if settings.AggregationSettings.Enabled {
+		linesIDs := make([]int64, len(settings.TrackerSettings.LinesSettings))
+		for i, lsettings := range settings.TrackerSettings.LinesSettings {
+			linesIDs[i] = lsettings.LineID
+		}
+		polygonsIDs := make([]int64, len(settings.TrackerSettings.PolygonsSettings))
+		for i, psettings := range settings.TrackerSettings.PolygonsSettings {
+			polygonsIDs[i] = psettings.PolygonID
+		}
+		app.aggregator = NewAggregator(settings.AggregationSettings.IntervalsDurations, linesIDs, polygonsIDs)
+		if settings.AggregationSettings.File != "" {
+			writer, err := NewAggregationWriter(settings.AggregationSettings.File)
+			if err != nil {
+				app.releaseResources()
+				return nil, errors.Wrap(err, "Can't prepare output of aggregation summaries")
+			}
+			app.aggregationWriter = writer
+			app.aggregator.SetHandler(writer.Handle)
+		}
+	}
+	/* Initialize export of finished tracks if needed */
+	if settings.TrajectoriesSettings.Enabled && !trajectoryConverterSupported(spatialConverter) {
+		fmt.Println("[WARNING] Export of trajectories requires speed estimation with mapper of type 'epsg4326', since GeoJSON coordinates should be WGS84. Disabling export of trajectories...")
+		settings.TrajectoriesSettings.Enabled = false
+	}
+	if settings.TrajectoriesSettings.Enabled {
+		writer, err := NewTrajectoryWriter(settings.TrajectoriesSettings.File, settings.TrajectoriesSettings.GetFormatType())
+		if err != nil {
+			app.releaseResources()
+			return nil, errors.Wrap(err, "Can't prepare export of trajectories")
+		}
+		app.trajectoryWriter = writer
+	}
+	return &app, nil
 }
 
-// Close Free memory for underlying objects
-func (app *Application) Close() {
-	app.neuralNetwork.Close()
+// releaseResources Frees memory and closes files which are owned by application (except detector, virtual polygons and gRPC connection)
+func (app *Application) releaseResources() {
 	app.gisConverter.Close()
-	if app.settings.GrpcSettings.Enable {
-		app.grpcConn.Close()
+	if app.undistorter != nil {
+		app.undistorter.Close()
+	}
+	if app.aggregationWriter != nil {
+		if err := app.aggregationWriter.Close(); err != nil {
+			fmt.Printf("Can't close file with aggregation summaries. Error: %s\n", err.Error())
+		}
+	}
+	if app.trajectoryWriter != nil {
+		if err := app.trajectoryWriter.Close(); err != nil {
+			fmt.Printf("Can't close file with trajectories. Error: %s\n", err.Error())
+		}
+	}
+}
+
+// checkReprojectionErrors Prints reprojection error for each point of mapper and checks it against tolerance
+// Returns error only if tolerance is exceeded and application should refuse to start
+func checkReprojectionErrors(converter *SpatialConverter, settings *SpeedEstimationSettings) error {
+	fmt.Printf("Mapper of type '%s' (homography method '%s'):\n", settings.MapperType, settings.HomographyMethod)
+	for i, reprojectionError := range converter.ReprojectionErrors {
+		status := "inlier"
+		if !converter.Inliers[i] {
+			status = "outlier"
+		}
+		fmt.Printf("\tPoint #%d: reprojection error = %.3f px (%s)\n", i, reprojectionError, status)
+	}
+	if settings.MaxReprojectionError == 0 {
+		return nil
+	}
+	maxError := converter.MaxReprojectionError()
+	if maxError <= settings.MaxReprojectionError {
+		return nil
+	}
+	if settings.RefuseOnReprojectionError {
+		return fmt.Errorf("Reprojection error of mapper in 'speed_estimation_settings' is %.3f px, which exceeds 'max_reprojection_error' = %.3f px", maxError, settings.MaxReprojectionError)
 	}
+	fmt.Printf("[WARNING] Reprojection error of mapper in 'speed_estimation_settings' is %.3f px, which exceeds 'max_reprojection_error' = %.3f px. Speed estimation could be inaccurate\n", maxError, settings.MaxReprojectionError)
+	return nil
+}
+
+// Close Free memory for underlying objects
+// It is safe to call it multiple times
+func (app *Application) Close() {
+	app.closeOnce.Do(func() {
+		app.detector.Close()
+		app.releaseResources()
+		for _, psettings := range app.settings.TrackerSettings.PolygonsSettings {
+			psettings.VPolygon.Close()
+		}
+		if app.grpcConn != nil {
+			app.grpcConn.Close()
+		}
+	})
+}
+
+// GetDetector Returns objects detector which is used by application
+func (app *Application) GetDetector() Detector {
+	return app.detector
 }
 
 // GetBlobsStorage Returns pointer to blob.Blobies
@@ -94,7 +216,25 @@ func (app *Application) GetBlobsStorage() *blob.Blobies {
 	return app.blobiesStorage
 }
 
-// GetGISConverter Returns anonymus function for spatial conversion
+// GetAggregator Returns statistics for virtual lines and polygons. Returns nil if aggregation is disabled
+func (app *Application) GetAggregator() *Aggregator {
+	return app.aggregator
+}
+
+// GetSpatialConverter Returns converter of image coordinates to real world ones
+func (app *Application) GetSpatialConverter() *SpatialConverter {
+	return app.gisConverter
+}
+
+// frameUndistorter Returns undistorter for frames. Returns nil if frames should not be undistorted
+func (app *Application) frameUndistorter() *Undistorter {
+	if app.undistorter == nil || app.settings.VideoSettings.Undistortion.GetModeType() != UNDISTORTION_MODE_FRAME {
+		return nil
+	}
+	return app.undistorter
+}
+
+// GetGISConverter Returns anonymus function for spatial conversion. It is identity function when speed estimation is disabled
 func (app *Application) GetGISConverter() func(gocv.Point2f) gocv.Point2f {
 	return app.gisConverter.Function
 }
@@ -136,12 +276,23 @@ func (app *Application) PrepareBlobs(detected DetectedObjects, lastTm time.Time,
 	return detectedObjects
 }
 
-func (app *Application) Run() error {
+// Run Starts processing of video stream
+// Frames go through the pipeline of stages (grabber → preprocess → detector → tracker → analytics) and then to the sinks (imshow() GUI and MJPEG)
+// It returns when video stream is finished, 'ESC' is pressed in imshow() GUI or provided context is done. Pending gRPC messages are flushed before return
+// Notice: it does not free memory for underlying objects, so Close() should be called by caller
+//
+// ctx - context for stopping processing
+//
+func (app *Application) Run(ctx context.Context) error {
 	settings := app.settings
 
+	if settings.Headless {
+		fmt.Println("Running in headless mode: no imshow() GUI, no MJPEG streaming, no drawing")
+	}
+
 	/* Open imshow() GUI in needed */
 	var window *gocv.Window
-	if settings.MjpegSettings.ImshowEnable {
+	if app.imshowEnabled() {
 		fmt.Println("Press 'ESC' to stop imshow()")
 		window = gocv.NewWindow("ODAM v0.9.0")
 		window.ResizeWindow(settings.VideoSettings.ReducedWidth, settings.VideoSettings.ReducedHeight)
@@ -153,225 +304,519 @@ func (app *Application) Run() error {
 	if err != nil {
 		return errors.Wrap(err, "Can't open video capture")
 	}
+	defer videoCapturer.Close()
 
-	/* Prepare frame */
-	img := NewFrameData()
-	/* Initialize variables for evaluation of time difference between frames */
-	lastMS := 0.0
-	lastTime := time.Now()
-
-	/* Initialize objects tracker */
-	allblobies := app.GetBlobsStorage()
 	fmt.Printf("Using tracker: '%s'\n", settings.TrackerSettings.TrackerType)
 
-	/* Initialize GIS converter (for speed estimation) if needed*/
-	// It just helps to figure out what does [Longitude; Latitude] pair correspond to certain pixel
-	var gisConverter func(gocv.Point2f) gocv.Point2f
-	if settings.TrackerSettings.SpeedEstimationSettings.Enabled {
-		gisConverter = app.GetGISConverter()
-	}
-
 	/* Initialize MJPEG server if needed */
 	var stream *mjpeg.Stream
-	if settings.MjpegSettings.Enable {
+	if app.mjpegEnabled() {
 		stream = app.StartMJPEGStream()
 	}
 
 	/* Initialize gRPC data forwarding if needed */
 	if settings.GrpcSettings.Enable {
 		url := fmt.Sprintf("%s:%d", settings.GrpcSettings.ServerIP, settings.GrpcSettings.ServerPort)
-		app.grpcConn, err = grpc.Dial(url, grpc.WithInsecure(), grpc.WithBlock())
+		// Context is used, so SIGINT/SIGTERM could interrupt blocking dial when server is unreachable
+		app.grpcConn, err = grpc.DialContext(ctx, url, grpc.WithInsecure(), grpc.WithBlock())
 		if err != nil {
 			return errors.Wrap(err, "Can't init grpc connection")
 		}
-		defer app.grpcConn.Close()
 		app.grpcClient = NewServiceYOLOClient(app.grpcConn)
 	}
 
-	/* Read frames in a */
-	for {
-		// Grab a frame
-		if ok := videoCapturer.Read(&img.ImgSource); !ok {
-			fmt.Println("Can't read next frame, stop grabbing...")
+	/* Start pipeline */
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pipe := newPipeline(app)
+	pipe.start(ctx, videoCapturer)
+
+	/* Sinks are handled in current goroutine, since imshow() GUI should not be used from different ones */
+	for pf := range pipe.output() {
+		stop := false
+		/* Draw info about detected objects when either MJPEG or imshow() GUI is enabled */
+		if app.drawingEnabled() {
+			// Notice: tracker stage could be ahead of this frame, so the most recent state of tracked objects is drawn
+			app.blobiesMutex.Lock()
+			app.draw(&pf.frame.ImgScaled)
+			app.blobiesMutex.Unlock()
+		}
+		if app.imshowEnabled() {
+			window.IMShow(pf.frame.ImgScaled)
+			if window.WaitKey(1) == 27 {
+				stop = true
+			}
+		}
+		if app.mjpegEnabled() {
+			buf, err := gocv.IMEncode(".jpg", pf.frame.ImgScaled)
+			if err != nil {
+				log.Printf("Error while decoding to JPG (mjpeg): %s", err.Error())
+			} else {
+				stream.UpdateJPEG(buf.GetBytes())
+			}
+		}
+		pf.close()
+		if stop {
 			break
 		}
-		/* Evaluate time difference */
-		currentMS := videoCapturer.Get(gocv.VideoCapturePosMsec)
-		msDiff := currentMS - lastMS
-		secDiff := msDiff / 1000.0
-		lastTime = lastTime.Add(time.Duration(msDiff) * time.Millisecond)
-		lastMS = currentMS
-
-		/* Skip empty frame */
-		if img.ImgSource.Empty() {
-			fmt.Println("Empty frame has been detected. Sleep for 400 ms")
-			time.Sleep(400 * time.Millisecond)
-			continue
+	}
+	// Stop every stage (if it has not been stopped yet) and hard release memory of frames left
+	cancel()
+	pipe.wait()
+	// Make sure that every event has been delivered
+	app.pendingSends.Wait()
+	// Report statistics for unfinished time buckets
+	if app.aggregator != nil {
+		app.aggregator.Flush()
+	}
+	// Tracks which have not been finished yet are finished by the end of video stream
+	if app.trajectoryWriter != nil {
+		app.blobiesMutex.Lock()
+		for _, b := range app.blobiesStorage.Objects {
+			app.exportTrajectory(b)
 		}
+		app.blobiesMutex.Unlock()
+	}
 
-		/* Scale frame */
-		err := img.Preprocess(settings.VideoSettings.ReducedWidth, settings.VideoSettings.ReducedHeight)
-		if err != nil {
-			fmt.Printf("Can't preprocess. Error: %s. Sleep for 400ms\n", err.Error())
-			time.Sleep(400 * time.Millisecond)
-			continue
-		}
+	// pprof (for debuggin purposes)
+	if settings.MatPPROFSettings.Enable {
+		var b bytes.Buffer
+		// go run -tags matprofile main.go
+		// gocv.MatProfile.WriteTo(&b, 1)
+		fmt.Print(b.String())
+	}
+	return nil
+}
 
-		detected := app.performDetectionSequential(img, settings.NeuralNetworkSettings.NetClasses, settings.NeuralNetworkSettings.TargetClasses)
-		if len(detected) != 0 {
-			/* Prepare 'blob' for each detected object */
-			detectedObjects := app.PrepareBlobs(detected, lastTime, secDiff)
-			/* Match blobs to existing ones */
-			allblobies.MatchToExisting(detectedObjects)
-			/* Estimate speed if needed */
-			if settings.TrackerSettings.SpeedEstimationSettings.Enabled {
-				for _, b := range allblobies.Objects {
-					blobTrack := b.GetTrack()
-					trackLen := len(blobTrack)
-					if trackLen >= 2 {
-						blobTimestamps := b.GetTimestamps()
-						fp := STDPointToGoCVPoint2F(blobTrack[0])
-						lp := STDPointToGoCVPoint2F(blobTrack[trackLen-1])
-						spd := EstimateSpeed(fp, lp, blobTimestamps[0], blobTimestamps[trackLen-1], gisConverter)
-						b.SetProperty("speed", spd)
-					}
-				}
+// trackObjects Matches detected objects to tracked ones, estimates speed and checks crossing of virtual lines
+// Events are collected into the frame, since blobs could be changed by the time next stages handle it
+//
+// pf - frame with detected objects
+// secDiff - time difference (in seconds) between this frame and previous one
+//
+func (app *Application) trackObjects(pf *pipelineFrame, secDiff float64) {
+	settings := app.settings
+	app.blobiesMutex.Lock()
+	defer app.blobiesMutex.Unlock()
+	allblobies := app.blobiesStorage
+	/* Start new time buckets for statistics if needed */
+	if app.aggregator != nil {
+		app.aggregator.Advance(pf.timestamp)
+	}
+	/* Remember tracked objects: some of them could be lost after matching */
+	trackedBefore := make([]blob.Blobie, 0, len(allblobies.Objects))
+	for _, b := range allblobies.Objects {
+		trackedBefore = append(trackedBefore, b)
+	}
+	/* Prepare 'blob' for each detected object */
+	detectedObjects := app.PrepareBlobs(pf.detected, pf.timestamp, secDiff)
+	/* Match blobs to existing ones */
+	// Notice: matching is done even if there are no detected objects, so tracker could lose objects which have gone
+	allblobies.MatchToExisting(detectedObjects)
+	for _, b := range trackedBefore {
+		if _, ok := allblobies.Objects[b.GetID()]; !ok {
+			app.handleLostBlob(pf, b)
+		}
+	}
+	/* Remember full track of each object if needed */
+	if app.trajectoryWriter != nil {
+		for _, b := range allblobies.Objects {
+			track, timestamps := b.GetTrack(), b.GetTimestamps()
+			if len(track) == 0 || len(timestamps) == 0 {
+				continue
 			}
-			for _, vline := range settings.TrackerSettings.LinesSettings {
-				for _, b := range allblobies.Objects {
-					className := b.GetClassName()
-					if stringInSlice(&className, vline.DetectClasses) { // Detect if object should be detected by virtual line (filter by classname)
-						crossedLine := vline.VLine.IsBlobCrossedLine(b)
-						// If object crossed the virtual line
-						if crossedLine {
-							b.SetTracking(false)
-							// If gRPC streaming data is disabled why do we need to process all stuff? We add strict condition.
-							if settings.GrpcSettings.Enable {
-								catchedTimestamp := time.Now().UTC().Unix()
-								blobRect := b.GetCurrentRect()
-								minx, miny := math.Floor(float64(blobRect.Min.X)*settings.VideoSettings.ScaleX), math.Floor(float64(blobRect.Min.Y)*settings.VideoSettings.ScaleY)
-								maxx, maxy := math.Floor(float64(blobRect.Max.X)*settings.VideoSettings.ScaleX), math.Floor(float64(blobRect.Max.Y)*settings.VideoSettings.ScaleY)
-								cropRect := image.Rect(
-									int(minx)+5,  // add a bit width to crop bigger region
-									int(miny)+10, // add a bit height to crop bigger region
-									int(maxx)+5,
-									int(maxy)+10,
-								)
-								// Make sure to be not out of image bounds
-								FixRectForOpenCV(&cropRect, settings.VideoSettings.Width, settings.VideoSettings.Height)
-								var buf *bytes.Buffer
-								xtop, ytop := int32(cropRect.Min.X), int32(cropRect.Min.Y)
-
-								// Futher buffer preparation depends on 'crop_mode' in JSON'ed configuration file
-								if vline.VLine.CropObject {
-									buf, err = PrepareCroppedImageBuffer(&img.ImgSource, cropRect)
-									if err != nil {
-										fmt.Println("[WARNING] Can't prepare image buffer (with crop) due ther error:", err)
-									}
-									xtop, ytop = 0, 0
-								} else {
-									buf, err = PrepareImageBuffer(&img.ImgSource)
-									if err != nil {
-										fmt.Println("[WARNING] Can't prepare image buffer due ther error:", err)
-									}
-								}
-								bytesBuffer := buf.Bytes()
-								sendData := ObjectInformation{
-									CamId:       settings.VideoSettings.CameraID,
-									Timestamp:   catchedTimestamp,
-									Image:       bytesBuffer,
-									Detection:   DetectionInfoGRPC(xtop, ytop, int32(cropRect.Dx()), int32(cropRect.Dy())),
-									Class:       ClassInfoGRPC(b),
-									VirtualLine: VirtualLineInfoGRPC(vline.LineID, vline.VLine),
-								}
-								// If it is needed to send speed and track information
-								if settings.TrackerSettings.SpeedEstimationSettings.SendGRPC {
-									sendData.TrackInformation = TrackInfoInfoGRPC(b, "speed", float32(settings.VideoSettings.ScaleX), float32(settings.VideoSettings.ScaleY), gisConverter)
-								}
-								go sendDataToServer(app.grpcClient, &sendData)
-							}
-						}
-					}
+			blobTrajectory(b).addPoint(track[len(track)-1], timestamps[len(timestamps)-1])
+		}
+	}
+	/* Estimate speed if needed */
+	if settings.TrackerSettings.SpeedEstimationSettings.Enabled {
+		speedSettings := &settings.TrackerSettings.SpeedEstimationSettings
+		for _, b := range allblobies.Objects {
+			// Track of object which has not been matched on this frame is unchanged: there is no new data for estimation and smoothing
+			if !blobTrackUpdated(b) {
+				continue
+			}
+			spd, ok := EstimateTrackSpeed(b.GetTrack(), b.GetTimestamps(), speedSettings, app.gisConverter)
+			if !ok {
+				continue
+			}
+			if speedSettings.Smoothing > 0 && speedSettings.Smoothing < 1 {
+				if previous, known := blobSpeed(b); known {
+					spd = SmoothSpeed(previous, spd, speedSettings.Smoothing)
 				}
 			}
-			// for _, vpolygon := range settings.TrackerSettings.PolygonsSettings {
-			// 	for _, b := range allblobies.Objects {
-			// 		className := b.GetClassName()
-			// 		if stringInSlice(&className, vpolygon.DetectClasses) { // Detect if object should be detected by virtual polygon (filter by classname)
-			// 			// if vpolygon.VPolygon.ContainsBlob(b) {
-			// 			// 	fmt.Printf("Polygon %d contains blob %s\n", vpolygon.PolygonID, b.GetID())
-			// 			// }
-			// 			// enteredPolygon := vpolygon.VPolygon.BlobEntered(b)
-			// 			// if enteredPolygon {
-			// 			// 	fmt.Println("entered blob", b.GetID())
-			// 			// }
-			// 			// leftPolygon := vpolygon.VPolygon.BlobLeft(b)
-			// 			// if leftPolygon {
-			// 			// 	fmt.Println("left blob", b.GetID())
-			// 			// }
-			// 		}
-			// 	}
-			// }
-		}
-		/* Draw info about detected objects when either MJPEG or imshow() GUI is enabled */
-		if settings.MjpegSettings.ImshowEnable || settings.MjpegSettings.Enable {
-			for i := range settings.TrackerSettings.LinesSettings {
-				settings.TrackerSettings.LinesSettings[i].VLine.Draw(&img.ImgScaled)
+			b.SetProperty("speed", spd)
+			// Estimation corresponds to the last point of track
+			timestamps := b.GetTimestamps()
+			trackedAt := timestamps[len(timestamps)-1]
+			if app.trajectoryWriter != nil {
+				blobTrajectory(b).addSpeed(spd, trackedAt)
 			}
-			for i := range settings.TrackerSettings.PolygonsSettings {
-				settings.TrackerSettings.PolygonsSettings[i].VPolygon.Draw(&img.ImgScaled)
+			if speedSettings.SpeedLimits.Enabled {
+				appendSpeedRecord(b, SpeedRecord{Timestamp: trackedAt, Speed: spd}, speedSettings.SpeedLimits.MinSamples)
 			}
-			for _, b := range allblobies.Objects {
-				spd := float32(0.0)
-				if spdInterface, ok := b.GetProperty("speed"); ok {
-					switch spdInterface.(type) { // Want to be sure that interface is float32
-					case float32:
-						spd = spdInterface.(float32)
-						break
-					default:
-						break
+		}
+	}
+	for _, vline := range settings.TrackerSettings.LinesSettings {
+		for _, b := range allblobies.Objects {
+			className := b.GetClassName()
+			if stringInSlice(&className, vline.DetectClasses) { // Detect if object should be detected by virtual line (filter by classname)
+				direction, crossedLine := vline.VLine.BlobCrossingDirection(b)
+				// If object crossed the virtual line
+				if crossedLine {
+					vline.VLine.RegisterCrossing(direction)
+					if app.aggregator != nil {
+						speed, speedKnown := blobSpeed(b)
+						app.aggregator.RegisterLineCrossing(vline.LineID, className, speed, speedKnown)
 					}
+					pf.lineEvents = append(pf.lineEvents, &lineCrossingEvent{
+						objectEvent: app.prepareObjectEvent(b),
+						line:        vline,
+						direction:   direction,
+					})
 				}
-				if foundOptions := settings.GetDrawOptions(b.GetClassName()); foundOptions != nil {
-					if foundOptions.DisplayObjectID {
-						b.DrawTrack(&img.ImgScaled, fmt.Sprintf("v = %.2f km/h", spd), fmt.Sprintf("%v", b.GetID()))
-					} else {
-						b.DrawTrack(&img.ImgScaled, fmt.Sprintf("v = %.2f km/h", spd))
+			}
+		}
+	}
+	for _, vpolygon := range settings.TrackerSettings.PolygonsSettings {
+		for _, b := range allblobies.Objects {
+			className := b.GetClassName()
+			if stringInSlice(&className, vpolygon.DetectClasses) { // Detect if object should be detected by virtual polygon (filter by classname)
+				blobID := b.GetID().String()
+				if vpolygon.VPolygon.BlobEntered(b) {
+					if app.aggregator != nil {
+						speed, speedKnown := blobSpeed(b)
+						app.aggregator.RegisterPolygonEntry(vpolygon.PolygonID, className, speed, speedKnown)
 					}
+					if app.trajectoryWriter != nil {
+						blobTrajectory(b).visitPolygon(vpolygon.PolygonID)
+					}
+					pf.polygonEvents = append(pf.polygonEvents, &polygonEvent{
+						objectEvent: app.prepareObjectEvent(b),
+						polygon:     vpolygon,
+						eventType:   PolygonEventType_POLYGON_EVENT_ENTERED,
+						visit:       vpolygon.VPolygon.StartVisit(blobID, className, pf.timestamp),
+					})
+				} else if vpolygon.VPolygon.BlobLeft(b) {
+					event := polygonEvent{
+						objectEvent: app.prepareObjectEvent(b),
+						polygon:     vpolygon,
+						eventType:   PolygonEventType_POLYGON_EVENT_LEFT,
+					}
+					// Object could enter polygon before it has been detected: there is no dwell time then
+					event.visit, _ = vpolygon.VPolygon.FinishVisit(blobID, pf.timestamp)
+					pf.polygonEvents = append(pf.polygonEvents, &event)
 				}
 			}
 		}
-		if settings.MjpegSettings.ImshowEnable {
-			window.IMShow(img.ImgScaled)
-			if window.WaitKey(1) == 27 {
-				break
+	}
+	/* Check speed limits after polygons, since limit could depend on zone which object is inside of */
+	if settings.TrackerSettings.SpeedEstimationSettings.SpeedLimits.Enabled {
+		app.checkSpeedViolations(pf)
+	}
+	if app.aggregator != nil {
+		app.sampleOccupancy()
+	}
+}
+
+// checkSpeedViolations Registers violations of speed limits. Each object violates limit only once
+// Notice: should be called under blobiesMutex
+func (app *Application) checkSpeedViolations(pf *pipelineFrame) {
+	limits := &app.settings.TrackerSettings.SpeedEstimationSettings.SpeedLimits
+	for _, b := range app.blobiesStorage.Objects {
+		if isBlobViolatedSpeed(b) {
+			continue
+		}
+		limit, zoneLimit, polygonID := app.speedLimit(b)
+		records := blobSpeedRecords(b)
+		if !speedLimitExceeded(records, limit, limits.MinSamples) {
+			continue
+		}
+		b.SetProperty(blobSpeedViolationProperty, true)
+		event := speedViolationEvent{
+			objectEvent: app.prepareObjectEvent(b),
+			limit:       limit,
+			zoneLimit:   zoneLimit,
+			polygonID:   polygonID,
+			records:     make([]SpeedRecord, limits.MinSamples),
+		}
+		copy(event.records, records[len(records)-limits.MinSamples:])
+		pf.violationEvents = append(pf.violationEvents, &event)
+	}
+}
+
+// speedLimit Returns speed limit for object
+// Limits of polygons which object is inside of override limit for its class. The strictest limit is chosen among polygons
+func (app *Application) speedLimit(b blob.Blobie) (limit float32, zoneLimit bool, polygonID int64) {
+	for _, id := range BlobPolygonsIDs(b) {
+		for _, psettings := range app.settings.TrackerSettings.PolygonsSettings {
+			if psettings.PolygonID != id || psettings.SpeedLimit <= 0 {
+				continue
+			}
+			if !zoneLimit || psettings.SpeedLimit < limit {
+				limit, zoneLimit, polygonID = psettings.SpeedLimit, true, id
 			}
 		}
-		if settings.MjpegSettings.Enable {
-			buf, err := gocv.IMEncode(".jpg", img.ImgScaled)
-			if err != nil {
-				log.Printf("Error while decoding to JPG (mjpeg): %s", err.Error())
-			} else {
-				stream.UpdateJPEG(buf.GetBytes())
+	}
+	if zoneLimit {
+		return limit, zoneLimit, polygonID
+	}
+	return app.settings.TrackerSettings.SpeedEstimationSettings.SpeedLimits.ClassLimit(b.GetClassName()), false, 0
+}
+
+// sampleOccupancy Registers number of objects on each virtual line and inside of each virtual polygon at current frame
+// Notice: should be called under blobiesMutex
+func (app *Application) sampleOccupancy() {
+	settings := app.settings
+	for _, vline := range settings.TrackerSettings.LinesSettings {
+		objectsNum := 0
+		for _, b := range app.blobiesStorage.Objects {
+			className := b.GetClassName()
+			if stringInSlice(&className, vline.DetectClasses) && vline.VLine.IntersectsRect(b.GetCurrentRect()) {
+				objectsNum++
 			}
 		}
+		app.aggregator.SampleLineOccupancy(vline.LineID, objectsNum)
 	}
-	// Hard release memory
-	img.Close()
-	app.Close()
+	for _, vpolygon := range settings.TrackerSettings.PolygonsSettings {
+		app.aggregator.SamplePolygonOccupancy(vpolygon.PolygonID, vpolygon.VPolygon.VisitorsCount())
+	}
+}
 
-	// pprof (for debuggin purposes)
-	if settings.MatPPROFSettings.Enable {
-		var b bytes.Buffer
-		// go run -tags matprofile main.go
-		// gocv.MatProfile.WriteTo(&b, 1)
-		fmt.Print(b.String())
+// handleLostBlob Finishes visits of virtual polygons and exports trajectory for object which has been lost by tracker
+//
+// pf - current frame
+// b - lost object
+//
+func (app *Application) handleLostBlob(pf *pipelineFrame, b blob.Blobie) {
+	blobID := b.GetID().String()
+	// Object has been seen last time at this moment
+	lastSeen := pf.timestamp
+	if timestamps := b.GetTimestamps(); len(timestamps) != 0 {
+		lastSeen = timestamps[len(timestamps)-1]
+	}
+	for _, vpolygon := range app.settings.TrackerSettings.PolygonsSettings {
+		visit, ok := vpolygon.VPolygon.FinishVisit(blobID, lastSeen)
+		if !ok {
+			continue
+		}
+		pf.polygonEvents = append(pf.polygonEvents, &polygonEvent{
+			objectEvent: app.prepareObjectEvent(b),
+			polygon:     vpolygon,
+			eventType:   PolygonEventType_POLYGON_EVENT_TRACK_LOST,
+			visit:       visit,
+		})
+	}
+	if app.trajectoryWriter != nil {
+		app.exportTrajectory(b)
+	}
+}
+
+// exportTrajectory Writes finished track of object
+// Notice: should be called under blobiesMutex
+func (app *Application) exportTrajectory(b blob.Blobie) {
+	feature, ok := TrajectoryFeature(b, app.settings.VideoSettings.CameraID, app.gisConverter)
+	if !ok {
+		return
+	}
+	if err := app.trajectoryWriter.Write(feature); err != nil {
+		fmt.Printf("Can't export trajectory of object '%s'. Error: %s\n", b.GetID().String(), err.Error())
+	}
+}
+
+// LineCounters Number of objects which have crossed virtual line in each direction
+type LineCounters struct {
+	ToDetector   uint64
+	FromDetector uint64
+}
+
+// LinesCounters Returns number of crossings in each direction for every virtual line (keyed by line's identifier)
+func (app *Application) LinesCounters() map[int64]LineCounters {
+	counters := make(map[int64]LineCounters, len(app.settings.TrackerSettings.LinesSettings))
+	for _, vline := range app.settings.TrackerSettings.LinesSettings {
+		counters[vline.LineID] = LineCounters{
+			ToDetector:   vline.VLine.CrossingsCount(LINE_DIRECTION_TO_DETECTOR),
+			FromDetector: vline.VLine.CrossingsCount(LINE_DIRECTION_FROM_DETECTOR),
+		}
+	}
+	return counters
+}
+
+// PolygonsVisitors Returns objects which are inside of each virtual polygon at the moment (keyed by polygon's identifier)
+// Use PolygonVisit.Dwell() to get time spent in polygon so far
+func (app *Application) PolygonsVisitors() map[int64][]PolygonVisit {
+	visitors := make(map[int64][]PolygonVisit, len(app.settings.TrackerSettings.PolygonsSettings))
+	for _, vpolygon := range app.settings.TrackerSettings.PolygonsSettings {
+		visitors[vpolygon.PolygonID] = vpolygon.VPolygon.Visitors()
+	}
+	return visitors
+}
+
+// prepareObjectEvent Collects information about object at the current moment
+func (app *Application) prepareObjectEvent(b blob.Blobie) objectEvent {
+	settings := app.settings
+	event := objectEvent{
+		timestamp: time.Now().UTC().Unix(),
+		rect:      b.GetCurrentRect(),
+		class:     ClassInfoGRPC(b),
+	}
+	// If it is needed to send speed and track information
+	if settings.GrpcSettings.Enable && settings.TrackerSettings.SpeedEstimationSettings.SendGRPC {
+		event.track = TrackInfoInfoGRPC(b, "speed", float32(settings.VideoSettings.ScaleX), float32(settings.VideoSettings.ScaleY), app.gisConverter)
+	}
+	return event
+}
+
+// analyzeFrame Prepares and sends information about events which have been collected by tracker stage
+func (app *Application) analyzeFrame(pf *pipelineFrame) {
+	// If gRPC streaming data is disabled why do we need to process all stuff? We add strict condition.
+	if !app.settings.GrpcSettings.Enable {
+		return
+	}
+	for _, event := range pf.lineEvents {
+		sendData, err := app.prepareObjectInformation(&pf.frame.ImgSource, &event.objectEvent, event.line.VLine.CropObject)
+		if err != nil {
+			fmt.Printf("[WARNING] Can't prepare information about crossing of line (id = '%d') due the error: %s\n", event.line.LineID, err.Error())
+			continue
+		}
+		sendData.VirtualLine = VirtualLineInfoGRPC(event.line.LineID, event.line.VLine)
+		sendData.VirtualLine.Direction = LineDirectionGRPC(event.direction)
+		app.sendData(sendData)
+	}
+	for _, event := range pf.polygonEvents {
+		sendData, err := app.prepareObjectInformation(&pf.frame.ImgSource, &event.objectEvent, event.polygon.VPolygon.CropObject)
+		if err != nil {
+			fmt.Printf("[WARNING] Can't prepare information about polygon (id = '%d') event due the error: %s\n", event.polygon.PolygonID, err.Error())
+			continue
+		}
+		sendData.VirtualPolygon = VirtualPolygonInfoGRPC(event.polygon.PolygonID, event.polygon.VPolygon, event.eventType)
+		if !event.visit.EnteredAt.IsZero() {
+			sendData.VirtualPolygon.EnteredAt = event.visit.EnteredAt.UTC().Unix()
+			if !event.visit.LeftAt.IsZero() {
+				sendData.VirtualPolygon.DwellSeconds = float32(event.visit.Dwell(event.visit.LeftAt).Seconds())
+			}
+		}
+		app.sendData(sendData)
+	}
+	for _, event := range pf.violationEvents {
+		// Crop of object is always sent, full frame goes with violation information
+		sendData, err := app.prepareObjectInformation(&pf.frame.ImgSource, &event.objectEvent, true)
+		if err != nil {
+			fmt.Printf("[WARNING] Can't prepare information about speed limit violation due the error: %s\n", err.Error())
+			continue
+		}
+		fullImage, err := PrepareImageBuffer(&pf.frame.ImgSource)
+		if err != nil {
+			fmt.Printf("[WARNING] Can't prepare full frame for speed limit violation due the error: %s\n", err.Error())
+			continue
+		}
+		sendData.SpeedViolation = SpeedViolationInfoGRPC(event.limit, event.zoneLimit, event.polygonID, event.records, fullImage.Bytes())
+		app.sendData(sendData)
+	}
+}
+
+// prepareObjectInformation Prepares gRPC message with image buffer for the event
+//
+// img - source (not scaled) image
+// event - information about object
+// cropObject - send cropped object instead of full image
+//
+func (app *Application) prepareObjectInformation(img *gocv.Mat, event *objectEvent, cropObject bool) (*ObjectInformation, error) {
+	settings := app.settings
+	blobRect := event.rect
+	minx, miny := math.Floor(float64(blobRect.Min.X)*settings.VideoSettings.ScaleX), math.Floor(float64(blobRect.Min.Y)*settings.VideoSettings.ScaleY)
+	maxx, maxy := math.Floor(float64(blobRect.Max.X)*settings.VideoSettings.ScaleX), math.Floor(float64(blobRect.Max.Y)*settings.VideoSettings.ScaleY)
+	cropRect := image.Rect(
+		int(minx)+5,  // add a bit width to crop bigger region
+		int(miny)+10, // add a bit height to crop bigger region
+		int(maxx)+5,
+		int(maxy)+10,
+	)
+	// Make sure to be not out of image bounds
+	FixRectForOpenCV(&cropRect, settings.VideoSettings.Width, settings.VideoSettings.Height)
+	var buf *bytes.Buffer
+	var err error
+	xtop, ytop := int32(cropRect.Min.X), int32(cropRect.Min.Y)
+
+	// Futher buffer preparation depends on 'crop_mode' in JSON'ed configuration file
+	if cropObject {
+		buf, err = PrepareCroppedImageBuffer(img, cropRect)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't prepare image buffer (with crop)")
+		}
+		xtop, ytop = 0, 0
+	} else {
+		buf, err = PrepareImageBuffer(img)
+		if err != nil {
+			return nil, errors.Wrap(err, "Can't prepare image buffer")
+		}
+	}
+	return &ObjectInformation{
+		CamId:            settings.VideoSettings.CameraID,
+		Timestamp:        event.timestamp,
+		Image:            buf.Bytes(),
+		Detection:        DetectionInfoGRPC(xtop, ytop, int32(cropRect.Dx()), int32(cropRect.Dy())),
+		Class:            event.class,
+		TrackInformation: event.track,
+	}, nil
+}
+
+// sendData Sends message to gRPC server in separate goroutine
+func (app *Application) sendData(data *ObjectInformation) {
+	app.pendingSends.Add(1)
+	go func() {
+		defer app.pendingSends.Done()
+		sendDataToServer(app.grpcClient, data)
+	}()
+}
+
+// imshowEnabled Checks if imshow() GUI should be used
+func (app *Application) imshowEnabled() bool {
+	return !app.settings.Headless && app.settings.MjpegSettings.ImshowEnable
+}
+
+// mjpegEnabled Checks if MJPEG streaming should be used
+func (app *Application) mjpegEnabled() bool {
+	return !app.settings.Headless && app.settings.MjpegSettings.Enable
+}
+
+// drawingEnabled Checks if there is any visual output which needs drawing
+func (app *Application) drawingEnabled() bool {
+	return app.imshowEnabled() || app.mjpegEnabled()
+}
+
+// draw Draws virtual lines, virtual polygons and tracked objects on provided image
+func (app *Application) draw(img *gocv.Mat) {
+	settings := app.settings
+	for i := range settings.TrackerSettings.LinesSettings {
+		settings.TrackerSettings.LinesSettings[i].VLine.Draw(img)
+	}
+	for i := range settings.TrackerSettings.PolygonsSettings {
+		settings.TrackerSettings.PolygonsSettings[i].VPolygon.Draw(img)
+	}
+	for _, b := range app.blobiesStorage.Objects {
+		spd := float32(0.0)
+		if spdInterface, ok := b.GetProperty("speed"); ok {
+			switch spdInterface.(type) { // Want to be sure that interface is float32
+			case float32:
+				spd = spdInterface.(float32)
+				break
+			default:
+				break
+			}
+		}
+		if foundOptions := settings.GetDrawOptions(b.GetClassName()); foundOptions != nil {
+			if foundOptions.DisplayObjectID {
+				b.DrawTrack(img, fmt.Sprintf("v = %.2f km/h", spd), fmt.Sprintf("%v", b.GetID()))
+			} else {
+				b.DrawTrack(img, fmt.Sprintf("v = %.2f km/h", spd))
+			}
+		}
 	}
-	return nil
 }
 
-func (app *Application) performDetectionSequential(frame *FrameData, netClasses, targetClasses []string) []*DetectedObject {
-	detectedRects, err := DetectObjects(app, frame.ImgScaledCopy, netClasses, targetClasses...)
+func (app *Application) performDetectionSequential(frame *FrameData) DetectedObjects {
+	detectedRects, err := app.detector.Detect(frame.ImgScaledCopy)
 	if err != nil {
 		log.Printf("Can't detect objects on provided image due the error: %s. Sleep for 100ms", err.Error())
 		frame.ImgScaledCopy.Close()
@@ -0,0 +1,39 @@
+package odam
+
+import (
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+// countingDetector Fake detector which counts calls of Close()
+type countingDetector struct {
+	closed int
+}
+
+func (cd *countingDetector) Detect(img gocv.Mat) (DetectedObjects, error) {
+	return DetectedObjects{}, nil
+}
+
+func (cd *countingDetector) Close() error {
+	cd.closed++
+	return nil
+}
+
+func TestApplicationCloseIdempotent(t *testing.T) {
+	settings := AppSettings{
+		VideoSettings:   &VideoSettings{},
+		TrackerSettings: &TrackerSettings{},
+	}
+	detector := countingDetector{}
+	app, err := NewAppWithDetector(&settings, &detector)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	app.Close()
+	app.Close()
+	if detector.closed != 1 {
+		t.Errorf("Detector should be closed exactly once, but it has been closed %d times", detector.closed)
+	}
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/LdDl/odam"
 )
 
 func main() {
 	settingsFile := flag.String("settings", "conf.json", "Path to application's settings")
+	headless := flag.Bool("headless", false, "Run without imshow() GUI and MJPEG streaming (no drawing, events only)")
 	/* Read settings */
 	flag.Parse()
 	settings, err := odam.NewSettings(*settingsFile)
@@ -16,6 +21,9 @@ func main() {
 		log.Println(err)
 		return
 	}
+	if *headless {
+		settings.SetHeadless()
+	}
 
 	/* Initialize application */
 	app, err := odam.NewApp(settings)
@@ -25,7 +33,20 @@ func main() {
 	}
 	defer app.Close()
 
-	err = app.Run()
+	/* Stop application gracefully on SIGINT/SIGTERM */
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Got signal '%s', stopping... Send it again to terminate immediately\n", sig)
+		// Restore default behaviour, so next signal terminates application
+		signal.Stop(signals)
+		cancel()
+	}()
+
+	err = app.Run(ctx)
 	if err != nil {
 		log.Println(err)
 		return
@@ -34,6 +34,16 @@ func NewSettings(fname string) (*AppSettings, error) {
 		return nil, errors.Wrap(err, "Can't read Darknet's classes file")
 	}
 	appsettings.NeuralNetworkSettings.NetClasses = strings.Split(string(content), "\n")
+	err = appsettings.NeuralNetworkSettings.Prepare(appsettings.ClassesSettings)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't prepare neural network settings")
+	}
+
+	// Prepare inference settings
+	err = appsettings.InferenceSettings.Prepare(appsettings.CudaSettings.Enable)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't prepare inference settings")
+	}
 
 	// Prepare video settings
 	if appsettings.VideoSettings == nil {
@@ -41,6 +51,16 @@ func NewSettings(fname string) (*AppSettings, error) {
 	}
 	appsettings.VideoSettings.Prepare()
 
+	if appsettings.Headless {
+		appsettings.SetHeadless()
+	}
+
+	// Prepare queues between pipeline stages
+	err = appsettings.PipelineSettings.Prepare()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't prepare pipeline settings")
+	}
+
 	// Prepare tracker settings
 	if appsettings.TrackerSettings == nil {
 		return nil, fmt.Errorf("Field 'tracker_settings' has not been provided in configuration file")
@@ -55,6 +75,12 @@ func NewSettings(fname string) (*AppSettings, error) {
 		psettings.VPolygon.Scale(appsettings.VideoSettings.ScaleX, appsettings.VideoSettings.ScaleY)
 	}
 
+	// Prepare aggregation of events
+	appsettings.AggregationSettings.Prepare()
+
+	// Prepare export of finished tracks
+	appsettings.TrajectoriesSettings.Prepare()
+
 	// Prepare drawing options for each class defined in 'neural_network_settings'
 	appsettings.ClassesDrawOptions = make(map[string]*DrawOptions)
 	for _, class := range appsettings.NeuralNetworkSettings.TargetClasses {
@@ -83,17 +109,36 @@ type AppSettings struct {
 	VideoSettings         *VideoSettings        `json:"video_settings"`
 	NeuralNetworkSettings NeuralNetworkSettings `json:"neural_network_settings"`
 	CudaSettings          CudaSettings          `json:"cuda_settings"`
+	InferenceSettings     InferenceSettings     `json:"inference_settings"`
 	MjpegSettings         MjpegSettings         `json:"mjpeg_settings"`
 	GrpcSettings          GrpcSettings          `json:"grpc_settings"`
 	ClassesSettings       []*ClassesSettings    `json:"classes_settings"`
 	TrackerSettings       *TrackerSettings      `json:"tracker_settings"`
 	MatPPROFSettings      MatPPROFSettings      `json:"matpprof_settings"`
+	PipelineSettings      PipelineSettings      `json:"pipeline_settings"`
+	AggregationSettings   AggregationSettings   `json:"aggregation_settings"`
+	TrajectoriesSettings  TrajectoriesSettings  `json:"trajectories_settings"`
+	// Run without any GUI or MJPEG streaming: no drawing, events only
+	Headless bool `json:"headless"`
 
 	sync.RWMutex
 	// Exported, but not from JSON
 	ClassesDrawOptions map[string]*DrawOptions `json:"-"`
 }
 
+// SetHeadless Enables headless mode: imshow() GUI and MJPEG streaming are disabled, nothing is drawn
+func (settings *AppSettings) SetHeadless() {
+	settings.Headless = true
+	if settings.MjpegSettings.ImshowEnable {
+		fmt.Println("[WARNING] Field 'imshow_enable' in 'mjpeg_settings' is ignored in headless mode")
+		settings.MjpegSettings.ImshowEnable = false
+	}
+	if settings.MjpegSettings.Enable {
+		fmt.Println("[WARNING] Field 'enable' in 'mjpeg_settings' is ignored in headless mode")
+		settings.MjpegSettings.Enable = false
+	}
+}
+
 func (settings *AppSettings) GetDrawOptions(className string) *DrawOptions {
 	settings.Lock()
 	found, ok := settings.ClassesDrawOptions[className]
@@ -125,6 +170,8 @@ type GrpcSettings struct {
 type ClassesSettings struct {
 	// Classname basically
 	ClassName string `json:"class_name"`
+	// Confidence threshold for this class. If it is not provided (or <= 0) then 'conf_threshold' from 'neural_network_settings' is used
+	ConfThreshold float64 `json:"conf_threshold"`
 	// Options for visual output (usefull when either imshow or mjpeg output is used)
 	DrawingSettings *ObjectDrawingSettings `json:"drawing_settings"`
 }
@@ -148,23 +195,13 @@ type MjpegSettings struct {
 	Port         int  `json:"port"`
 }
 
-// NeuralNetworkSettings Neural network
-type NeuralNetworkSettings struct {
-	DarknetCFG     string  `json:"darknet_cfg"`
-	DarknetWeights string  `json:"darknet_weights"`
-	DarknetClasses string  `json:"darknet_classes"`
-	ConfThreshold  float64 `json:"conf_threshold"`
-	NmsThreshold   float64 `json:"nms_threshold"`
-	// Exported, but not from JSON
-	NetClasses    []string `json:"-"`
-	TargetClasses []string `json:"target_classes"`
-}
-
 // LinesSetting Virtual lines
 type LinesSetting struct {
-	LineID        int64    `json:"line_id"`
-	Begin         [2]int   `json:"begin"`
-	End           [2]int   `json:"end"`
+	LineID int64  `json:"line_id"`
+	Begin  [2]int `json:"begin"`
+	End    [2]int `json:"end"`
+	// Vertices of polyline. If it is provided then 'begin' and 'end' are ignored
+	Points        [][2]int `json:"points"`
 	Direction     string   `json:"direction"`
 	DetectClasses []string `json:"detect_classes"`
 	RGBA          [4]uint8 `json:"rgba"`
@@ -179,6 +216,13 @@ type PolygonsSetting struct {
 	Coordinates   [][2]int `json:"coordinates"`
 	DetectClasses []string `json:"detect_classes"`
 	RGBA          [4]uint8 `json:"rgba"`
+	CropMode      string   `json:"crop_mode"`
+	// Possible values are: center, bottom_center, any_corner, overlap
+	Anchor string `json:"anchor"`
+	// Minimum fraction of bounding box area covered by polygon (for 'overlap' anchor)
+	MinOverlap float64 `json:"min_overlap"`
+	// Speed limit inside of polygon. It overrides limits for classes (see ref. SpeedLimitsSettings). Zero means no limit
+	SpeedLimit float32 `json:"speed_limit"`
 	// Exported, but not from JSON
 	VPolygon *VirtualPolygon `json:"-"`
 }
@@ -210,12 +254,77 @@ type SpeedEstimationSettings struct {
 	Enabled bool `json:"enabled"`
 	// Is gRPC sending needed? If yes make sure that 'grpc_settings.enable' is set to 'true' also
 	SendGRPC bool `json:"send_grpc"`
-	// Map image coordinates to GIS coordinates. EPSG 4326 is handled only currently
+	// Possible values are: epsg4326, metric. Default is epsg4326
+	MapperType string `json:"mapper_type"`
+	// Map image coordinates to GIS coordinates (EPSG 4326) or to local metric coordinates
 	Mapper []GISMapper `json:"mapper"`
+	// Known dimensions of lane section. Could be used instead of 'mapper' for 'metric' mapper type
+	Lane *LaneMapper `json:"lane"`
+	// Possible values are: least_squares, ransac. Default is least_squares
+	HomographyMethod string `json:"homography_method"`
+	// Maximum reprojection error (in pixels) for point to be considered as inlier by RANSAC
+	RansacThreshold float64 `json:"ransac_threshold"`
+	// Tolerance for reprojection error (in pixels) of mapper's points. Zero means no check
+	MaxReprojectionError float64 `json:"max_reprojection_error"`
+	// Should application refuse to start when reprojection error exceeds tolerance? Otherwise warning is printed only
+	RefuseOnReprojectionError bool `json:"refuse_on_reprojection_error"`
+	// Limits of speed for violation events
+	SpeedLimits SpeedLimitsSettings `json:"speed_limits"`
+	// Possible values are: first_last, last_n_points, last_seconds, linear_regression. Default is first_last
+	Estimator string `json:"estimator"`
+	// Number of last points of track for 'last_n_points' and 'linear_regression' estimators
+	WindowPoints int `json:"window_points"`
+	// Duration of time window (in seconds) for 'last_seconds' estimator
+	WindowSeconds float64 `json:"window_seconds"`
+	// Factor of exponential smoothing in (0; 1]. Zero or one disables smoothing
+	Smoothing float64 `json:"smoothing"`
+
+	estimatorType    SPEED_ESTIMATOR
+	mapperType       MAPPER_TYPE
+	homographyMethod HOMOGRAPHY_METHOD
+}
+
+// GetHomographyMethod Returns enum for homography method option
+func (ses *SpeedEstimationSettings) GetHomographyMethod() HOMOGRAPHY_METHOD {
+	return ses.homographyMethod
+}
+
+// GetMapperType Returns enum for mapper type option
+func (ses *SpeedEstimationSettings) GetMapperType() MAPPER_TYPE {
+	return ses.mapperType
+}
+
+// GetEstimatorType Returns enum for speed estimator option
+func (ses *SpeedEstimationSettings) GetEstimatorType() SPEED_ESTIMATOR {
+	return ses.estimatorType
+}
+
+// SpeedLimitsSettings Limits of speed for violation events
+type SpeedLimitsSettings struct {
+	// Is this feature enabled? Speed estimation should be enabled also
+	Enabled bool `json:"enabled"`
+	// Limit for classes which are not listed in 'classes'. Zero means no limit
+	Default float32 `json:"default"`
+	// Limit for each class
+	Classes map[string]float32 `json:"classes"`
+	// Number of consecutive speed estimations above limit which are needed for violation. Helps to avoid violations due jitter of bounding boxes
+	MinSamples int `json:"min_samples"`
 }
 
 // GISMapper Map image coordinates to GIS coordinates
 type GISMapper struct {
 	ImageCoordinates [2]float32 `json:"image_coordinates"`
 	EPSG4326         [2]float32 `json:"epsg4326"`
+	// Local planar coordinates in meters (for 'metric' mapper type)
+	Metric [2]float32 `json:"metric"`
+}
+
+// LaneMapper Map corners of lane section with known dimensions to local metric coordinates
+type LaneMapper struct {
+	// Corners of lane section in image: near left -> near right -> far right -> far left
+	ImageCoordinates [4][2]float32 `json:"image_coordinates"`
+	// Width of lane section in meters
+	Width float32 `json:"width"`
+	// Length of lane section in meters
+	Length float32 `json:"length"`
 }
@@ -57,65 +57,81 @@ func (do *DetectedObject) GetSpeed() float32 {
 	return do.speed
 }
 
-const (
-	yoloScaleFactor = 1.0 / 255.0
-	yoloHeight      = 608
-	yoloWidth       = 608
-)
-
 var (
-	yoloSize     = image.Point{yoloHeight, yoloWidth}
-	yoloMean     = gocv.NewScalar(0.0, 0.0, 0.0, 0.0)
 	yoloBlobName = ""
 )
 
-// DetectObjects Detect objects for provided Go's image via neural network
+// postprocessOptions Options for extracting detected objects from network's output
+type postprocessOptions struct {
+	// Format of network's output
+	modelFormat MODEL_FORMAT
+	// Default confidence threshold
+	confidenceThreshold float32
+	// Threshold for non-maximum suppression
+	nmsThreshold float32
+	// Optional confidence thresholds for certain classes (overrides default one)
+	classesThresholds map[string]float32
+	// Size of network's input
+	inputWidth  float32
+	inputHeight float32
+	// Has image been letterboxed (resized with keeping aspect ratio and padded) before passing to network
+	letterbox bool
+	// Neural network predefined classes
+	netClasses []string
+	// List of classes for which you need to filter detected objects
+	filters []string
+}
+
+// postprocess Extracts detected objects from YOLO's output layers
 //
-// app - Application instance containing pointer to neural network for object detection
-// img - gocv.Mat image object
-// netClasses - neural network predefined classes
-// filters - List of classes for which you need to filter detected objects
+// detections - output layers of neural network
+// frameWidth, frameHeight - size of image which has been passed to neural network
+// options - decoding, filtering and NMS options
 //
-func DetectObjects(app *Application, img gocv.Mat, netClasses []string, filters ...string) ([]*DetectedObject, error) {
-	blobImg := gocv.BlobFromImage(img, yoloScaleFactor, yoloSize, yoloMean, true, false)
-	defer blobImg.Close()
-	app.neuralNetwork.SetInput(blobImg, yoloBlobName)
-	detections := app.neuralNetwork.ForwardLayers(app.layersNames)
-	detected, err := postprocess(detections, 0.5, 0.4, float32(img.Cols()), float32(img.Rows()), netClasses, filters)
-	for i := range detections {
-		err := detections[i].Close()
-		if err != nil {
-			return detected, errors.Wrap(err, "Can't deallocate gocv.Mat")
+func postprocess(detections []gocv.Mat, frameWidth, frameHeight float32, options *postprocessOptions) ([]*DetectedObject, error) {
+	decoder, err := getOutputDecoder(options.modelFormat)
+	if err != nil {
+		return nil, err
+	}
+	// Minimal threshold is needed for NMS, otherwise objects of classes with lower confidence threshold would be filtered out
+	nmsScoreThreshold := options.confidenceThreshold
+	for _, threshold := range options.classesThresholds {
+		if threshold < nmsScoreThreshold {
+			nmsScoreThreshold = threshold
 		}
 	}
-	return detected, err
-}
-
-func postprocess(detections []gocv.Mat, confidenceThreshold, nmsThreshold float32, frameWidth, frameHeight float32, netClasses []string, filters []string) ([]*DetectedObject, error) {
+	var lb *letterboxTransform
+	if options.letterbox {
+		lb = newLetterboxTransform(frameWidth, frameHeight, options.inputWidth, options.inputHeight)
+	}
 	detectedObjects := []*DetectedObject{}
 	bboxes := []image.Rectangle{}
 	confidences := []float32{}
-	for i, yoloLayer := range detections {
-		cols := yoloLayer.Cols()
-		data, err := detections[i].DataPtrFloat32()
+	for i := range detections {
+		candidates, err := decoder(detections[i], options.inputWidth, options.inputHeight)
 		if err != nil {
-			return nil, errors.Wrap(err, "Can't extract data")
+			return nil, errors.Wrapf(err, "Can't decode output layer #%d", i)
 		}
-		for j := 0; j < yoloLayer.Total(); j += cols {
-			row := data[j : j+cols]
-			scores := row[5:]
-			classID, confidence := getClassIDAndConfidence(scores)
-			className := netClasses[classID]
-			if stringInSlice(&className, filters) {
-				if confidence > confidenceThreshold {
-					confidences = append(confidences, confidence)
-					boundingBox := calculateBoundingBox(frameWidth, frameHeight, row)
+		for _, candidate := range candidates {
+			if candidate.classID >= len(options.netClasses) {
+				// Model has been trained for more classes than provided in classes file
+				continue
+			}
+			className := options.netClasses[candidate.classID]
+			if stringInSlice(&className, options.filters) {
+				classThreshold := options.confidenceThreshold
+				if threshold, ok := options.classesThresholds[className]; ok {
+					classThreshold = threshold
+				}
+				if candidate.confidence > classThreshold {
+					confidences = append(confidences, candidate.confidence)
+					boundingBox := calculateBoundingBox(frameWidth, frameHeight, candidate.box[:], lb)
 					bboxes = append(bboxes, boundingBox)
 					detectedObjects = append(detectedObjects, &DetectedObject{
 						Rect:       boundingBox,
 						ClassName:  className,
-						ClassID:    classID,
-						Confidence: confidence,
+						ClassID:    candidate.classID,
+						Confidence: candidate.confidence,
 					})
 				}
 			}
@@ -128,12 +144,12 @@ func postprocess(detections []gocv.Mat, confidenceThreshold, nmsThreshold float3
 	for i := range indices {
 		indices[i] = -1
 	}
-	gocv.NMSBoxes(bboxes, confidences, confidenceThreshold, nmsThreshold, indices)
+	gocv.NMSBoxes(bboxes, confidences, nmsScoreThreshold, options.nmsThreshold, indices)
 	filteredDetectedObjects := make([]*DetectedObject, 0, len(detectedObjects))
-	for i, idx := range indices {
-		if idx < 0 || (i != 0 && idx == 0) {
-			// Eliminate zeros, since they are filtered by NMS (except first element)
-			// Also filter all '-1' which are undefined by default
+	for _, idx := range indices {
+		if idx < 0 {
+			// Filter all '-1' which are undefined by default (NMS fills only first K elements)
+			// Note: zero is valid index and it could be at any position since NMS sorts indices by confidence
 			continue
 		}
 		filteredDetectedObjects = append(filteredDetectedObjects, detectedObjects[idx])
@@ -153,14 +169,26 @@ func getClassIDAndConfidence(x []float32) (int, float32) {
 	return res, max
 }
 
-func calculateBoundingBox(frameWidth, frameHeight float32, row []float32) image.Rectangle {
+// calculateBoundingBox Maps normalized (relative to network's input) box [cx, cy, w, h] to rectangle in frame's pixels
+//
+// frameWidth, frameHeight - size of image which has been passed to neural network
+// row - normalized box
+// lb - letterbox transformation which has been applied to image. If it is nil then image is assumed to be stretched to network's input
+//
+func calculateBoundingBox(frameWidth, frameHeight float32, row []float32, lb *letterboxTransform) image.Rectangle {
 	if len(row) < 4 {
 		return image.Rect(0, 0, 0, 0)
 	}
-	centerX := int(row[0] * frameWidth)
-	centerY := int(row[1] * frameHeight)
-	width := int(row[2] * frameWidth)
-	height := int(row[3] * frameHeight)
+	var centerX, centerY, width, height int
+	if lb != nil {
+		cx, cy, w, h := lb.unproject(row)
+		centerX, centerY, width, height = int(cx), int(cy), int(w), int(h)
+	} else {
+		centerX = int(row[0] * frameWidth)
+		centerY = int(row[1] * frameHeight)
+		width = int(row[2] * frameWidth)
+		height = int(row[3] * frameHeight)
+	}
 	left := (centerX - width/2)
 	top := (centerY - height/2)
 	return image.Rect(left, top, left+width, top+height)
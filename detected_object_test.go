@@ -0,0 +1,197 @@
+package odam
+
+import (
+	"image"
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+// prepareYOLOLayer Creates synthetic YOLO output layer: each row is [cx, cy, w, h, objectness, class scores...]
+func prepareYOLOLayer(rows [][]float32) gocv.Mat {
+	layer := gocv.NewMatWithSize(len(rows), len(rows[0]), gocv.MatTypeCV32F)
+	for i := range rows {
+		for j := range rows[i] {
+			layer.SetFloatAt(i, j, rows[i][j])
+		}
+	}
+	return layer
+}
+
+func TestPostprocess(t *testing.T) {
+	netClasses := []string{"person", "car", "truck"}
+	frameWidth, frameHeight := float32(200), float32(200)
+	// Rows are [cx, cy, w, h, objectness, person, car, truck]
+	carRow := []float32{0.5, 0.5, 0.25, 0.25, 0.95, 0.0, 0.9, 0.0}
+	carOverlappedRow := []float32{0.53125, 0.5, 0.25, 0.25, 0.95, 0.0, 0.8, 0.0}
+	weakCarRow := []float32{0.5, 0.5, 0.25, 0.25, 0.95, 0.0, 0.6, 0.0}
+	personRow := []float32{0.25, 0.25, 0.125, 0.25, 0.95, 0.3, 0.0, 0.0}
+	truckRow := []float32{0.75, 0.75, 0.25, 0.25, 0.95, 0.0, 0.0, 0.9}
+	weakTruckRow := []float32{0.75, 0.75, 0.25, 0.25, 0.95, 0.0, 0.0, 0.6}
+	carRect := image.Rect(75, 75, 125, 125)
+	personRect := image.Rect(38, 25, 63, 75)
+	truckRect := image.Rect(125, 125, 175, 175)
+
+	type expectedObject struct {
+		className string
+		rect      image.Rectangle
+	}
+	cases := []struct {
+		name              string
+		rows              [][]float32
+		classesThresholds map[string]float32
+		filters           []string
+		expected          []expectedObject
+	}{
+		{
+			name:     "Default threshold",
+			rows:     [][]float32{carRow, personRow},
+			filters:  netClasses,
+			expected: []expectedObject{{"car", carRect}},
+		},
+		{
+			name:              "Lower threshold for pedestrians",
+			rows:              [][]float32{carRow, personRow},
+			classesThresholds: map[string]float32{"person": 0.25},
+			filters:           netClasses,
+			expected:          []expectedObject{{"car", carRect}, {"person", personRect}},
+		},
+		{
+			name:              "Higher threshold for trucks",
+			rows:              [][]float32{carRow, weakTruckRow},
+			classesThresholds: map[string]float32{"truck": 0.7},
+			filters:           netClasses,
+			expected:          []expectedObject{{"car", carRect}},
+		},
+		{
+			name:     "Filter by target classes",
+			rows:     [][]float32{carRow, truckRow},
+			filters:  []string{"truck"},
+			expected: []expectedObject{{"truck", truckRect}},
+		},
+		{
+			name:     "Non-maximum suppression",
+			rows:     [][]float32{carRow, carOverlappedRow},
+			filters:  netClasses,
+			expected: []expectedObject{{"car", carRect}},
+		},
+		{
+			name:     "First candidate has lower confidence",
+			rows:     [][]float32{weakCarRow, truckRow},
+			filters:  netClasses,
+			expected: []expectedObject{{"truck", truckRect}, {"car", carRect}},
+		},
+		{
+			name:     "Nothing has been detected",
+			rows:     [][]float32{personRow},
+			filters:  netClasses,
+			expected: []expectedObject{},
+		},
+	}
+	for _, c := range cases {
+		layer := prepareYOLOLayer(c.rows)
+		options := postprocessOptions{
+			modelFormat:         MODEL_FORMAT_DARKNET,
+			confidenceThreshold: 0.5,
+			nmsThreshold:        0.4,
+			classesThresholds:   c.classesThresholds,
+			inputWidth:          416,
+			inputHeight:         416,
+			netClasses:          netClasses,
+			filters:             c.filters,
+		}
+		detected, err := postprocess([]gocv.Mat{layer}, frameWidth, frameHeight, &options)
+		layer.Close()
+		if err != nil {
+			t.Errorf("Case '%s': unexpected error: %s", c.name, err.Error())
+			continue
+		}
+		if len(detected) != len(c.expected) {
+			t.Errorf("Case '%s': should be %d detected objects, but got %d (%v)", c.name, len(c.expected), len(detected), detected)
+			continue
+		}
+		for i := range c.expected {
+			if detected[i].ClassName != c.expected[i].className {
+				t.Errorf("Case '%s': object #%d should be of class '%s', but got '%s'", c.name, i, c.expected[i].className, detected[i].ClassName)
+			}
+			if detected[i].Rect != c.expected[i].rect {
+				t.Errorf("Case '%s': object #%d should have bounding box %v, but got %v", c.name, i, c.expected[i].rect, detected[i].Rect)
+			}
+		}
+	}
+}
+
+// prepareONNXLayer Creates synthetic 3D output layer of shape [1, rows, cols]
+func prepareONNXLayer(rows [][]float32) gocv.Mat {
+	layer := gocv.NewMatWithSizes([]int{1, len(rows), len(rows[0])}, gocv.MatTypeCV32F)
+	for i := range rows {
+		for j := range rows[i] {
+			layer.SetFloatAt3(0, i, j, rows[i][j])
+		}
+	}
+	return layer
+}
+
+func TestPostprocessModelFormats(t *testing.T) {
+	netClasses := []string{"person", "car", "truck"}
+	frameWidth, frameHeight := float32(200), float32(200)
+	carRect := image.Rect(75, 75, 125, 125)
+	truckRect := image.Rect(125, 125, 175, 175)
+	cases := []struct {
+		modelFormat MODEL_FORMAT
+		rows        [][]float32
+		expected    []image.Rectangle
+	}{
+		{
+			// Rows are [cx, cy, w, h, objectness, person, car, truck] in pixels of network's input
+			// Second candidate has high class score, but low objectness
+			modelFormat: MODEL_FORMAT_YOLOV5_ONNX,
+			rows: [][]float32{
+				{320, 320, 160, 160, 0.9, 0.0, 0.95, 0.0},
+				{480, 480, 160, 160, 0.5, 0.0, 0.0, 0.9},
+			},
+			expected: []image.Rectangle{carRect},
+		},
+		{
+			// Output is transposed: rows are [cx], [cy], [w], [h], [person], [car], [truck] in pixels of network's input
+			modelFormat: MODEL_FORMAT_YOLOV8_ONNX,
+			rows: [][]float32{
+				{320, 480, 100},
+				{320, 480, 100},
+				{160, 160, 20},
+				{160, 160, 20},
+				{0.0, 0.0, 0.2},
+				{0.9, 0.0, 0.0},
+				{0.0, 0.75, 0.0},
+			},
+			expected: []image.Rectangle{carRect, truckRect},
+		},
+	}
+	for _, c := range cases {
+		layer := prepareONNXLayer(c.rows)
+		options := postprocessOptions{
+			modelFormat:         c.modelFormat,
+			confidenceThreshold: 0.5,
+			nmsThreshold:        0.4,
+			inputWidth:          640,
+			inputHeight:         640,
+			netClasses:          netClasses,
+			filters:             netClasses,
+		}
+		detected, err := postprocess([]gocv.Mat{layer}, frameWidth, frameHeight, &options)
+		layer.Close()
+		if err != nil {
+			t.Errorf("Model format '%s': unexpected error: %s", c.modelFormat, err.Error())
+			continue
+		}
+		if len(detected) != len(c.expected) {
+			t.Errorf("Model format '%s': should be %d detected objects, but got %d (%v)", c.modelFormat, len(c.expected), len(detected), detected)
+			continue
+		}
+		for i := range c.expected {
+			if detected[i].Rect != c.expected[i] {
+				t.Errorf("Model format '%s': object #%d should have bounding box %v, but got %v", c.modelFormat, i, c.expected[i], detected[i].Rect)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package odam
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/pkg/errors"
+	"gocv.io/x/gocv"
+)
+
+// Detector Common interface for object detection backends
+//
+// Application depends only on this interface, so any implementation (neural network, replay of saved detections, mock for tests and etc.) could be used
+type Detector interface {
+	// Detect Returns objects which have been found on provided image
+	Detect(img gocv.Mat) (DetectedObjects, error)
+	// Close Free memory for underlying objects
+	Close() error
+}
+
+// YOLODetector Detector implementation based on OpenCV's DNN module and YOLO neural network
+type YOLODetector struct {
+	neuralNetwork *gocv.Net
+	layersNames   []string
+
+	inputSize   image.Point
+	scaleFactor float64
+	mean        gocv.Scalar
+	swapRB      bool
+
+	postprocessOptions *postprocessOptions
+}
+
+// NewYOLODetector Constructor for YOLODetector
+//
+// settings - pointer to NeuralNetworkSettings object
+// inference - pointer to InferenceSettings object (backend and target device)
+//
+func NewYOLODetector(settings *NeuralNetworkSettings, inference *InferenceSettings) (*YOLODetector, error) {
+	var neuralNet gocv.Net
+	switch settings.ModelFormatType {
+	case MODEL_FORMAT_DARKNET:
+		neuralNet = gocv.ReadNet(settings.DarknetWeights, settings.DarknetCFG)
+		break
+	case MODEL_FORMAT_YOLOV5_ONNX, MODEL_FORMAT_YOLOV8_ONNX:
+		neuralNet = gocv.ReadNetFromONNX(settings.ONNXModel)
+		break
+	default:
+		return nil, fmt.Errorf("Model format '%s' is not supported", settings.ModelFormatType)
+	}
+	if neuralNet.Empty() {
+		neuralNet.Close()
+		return nil, fmt.Errorf("Can't read neural network of format '%s'", settings.ModelFormatType)
+	}
+	yoloLayersIdx := neuralNet.GetUnconnectedOutLayers()
+	outLayerNames := make([]string, 0, 3)
+	for _, idx := range yoloLayersIdx {
+		layer := neuralNet.GetLayer(idx)
+		outLayerNames = append(outLayerNames, layer.GetName())
+	}
+	err := neuralNet.SetPreferableBackend(inference.BackendType)
+	if err != nil {
+		neuralNet.Close()
+		return nil, errors.Wrapf(err, "Can't set backend '%s'", inference.Backend)
+	}
+	err = neuralNet.SetPreferableTarget(inference.TargetType)
+	if err != nil {
+		neuralNet.Close()
+		return nil, errors.Wrapf(err, "Can't set target '%s'", inference.Target)
+	}
+	return &YOLODetector{
+		neuralNetwork: &neuralNet,
+		layersNames:   outLayerNames,
+
+		inputSize:   image.Point{X: settings.InputWidth, Y: settings.InputHeight},
+		scaleFactor: settings.ScaleFactor,
+		mean:        gocv.NewScalar(settings.Mean[0], settings.Mean[1], settings.Mean[2], 0.0),
+		swapRB:      settings.SwapRB == nil || *settings.SwapRB,
+
+		postprocessOptions: &postprocessOptions{
+			modelFormat:         settings.ModelFormatType,
+			confidenceThreshold: float32(settings.ConfThreshold),
+			nmsThreshold:        float32(settings.NmsThreshold),
+			classesThresholds:   settings.ClassesThresholds,
+			inputWidth:          float32(settings.InputWidth),
+			inputHeight:         float32(settings.InputHeight),
+			letterbox:           settings.Letterbox,
+			netClasses:          settings.NetClasses,
+			filters:             settings.TargetClasses,
+		},
+	}, nil
+}
+
+// Detect Detect objects for provided image via neural network
+//
+// img - gocv.Mat image object
+//
+func (yd *YOLODetector) Detect(img gocv.Mat) (DetectedObjects, error) {
+	input := img
+	if yd.postprocessOptions.letterbox {
+		// Keep aspect ratio: resize and pad image up to network's input size
+		lb := newLetterboxTransform(float32(img.Cols()), float32(img.Rows()), float32(yd.inputSize.X), float32(yd.inputSize.Y))
+		input = lb.apply(img)
+		defer input.Close()
+	}
+	blobImg := gocv.BlobFromImage(input, yd.scaleFactor, yd.inputSize, yd.mean, yd.swapRB, false)
+	defer blobImg.Close()
+	yd.neuralNetwork.SetInput(blobImg, yoloBlobName)
+	detections := yd.neuralNetwork.ForwardLayers(yd.layersNames)
+	detected, err := postprocess(detections, float32(img.Cols()), float32(img.Rows()), yd.postprocessOptions)
+	for i := range detections {
+		err := detections[i].Close()
+		if err != nil {
+			return detected, errors.Wrap(err, "Can't deallocate gocv.Mat")
+		}
+	}
+	return detected, err
+}
+
+// Close Free memory for underlying neural network
+func (yd *YOLODetector) Close() error {
+	return yd.neuralNetwork.Close()
+}
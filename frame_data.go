@@ -32,7 +32,14 @@ func (fd *FrameData) Close() {
 }
 
 // Preprocess Scales image to given width and height
-func (fd *FrameData) Preprocess(width, height int) error {
+// If undistorter is provided then lens distortion of source image is corrected before scaling
+func (fd *FrameData) Preprocess(width, height int, undistorter *Undistorter) error {
+	if undistorter != nil {
+		undistorted := gocv.NewMat()
+		undistorter.UndistortFrame(fd.ImgSource, &undistorted)
+		fd.ImgSource.Close()
+		fd.ImgSource = undistorted
+	}
 	gocv.Resize(fd.ImgSource, &fd.ImgScaled, image.Point{X: width, Y: height}, 0, 0, gocv.InterpolationDefault)
 	fd.ImgScaledCopy = fd.ImgScaled.Clone()
 	return nil
@@ -0,0 +1,80 @@
+package odam
+
+// isOnSegment Checks if point Q lies on segment PR
+// Input: three colinear points Q, Q and R
+func isOnSegment(Px, Py, Qx, Qy, Rx, Ry int) bool {
+	if Qx <= maxInt(Px, Rx) && Qx >= minInt(Px, Rx) && Qy <= maxInt(Py, Ry) && Qy >= minInt(Py, Ry) {
+		return true
+	}
+	return false
+}
+
+// PointsOrientation Orientation of ordered triplet of points
+type PointsOrientation int
+
+const (
+	// Collinear Points lie on the same line
+	Collinear = iota
+	// Clockwise Points are ordered clockwise
+	Clockwise
+	// CounterClockwise Points are ordered counterclockwise
+	CounterClockwise
+)
+
+// getOrientation Gets orientations of points P -> Q -> R.
+// Possible output values: Collinear / Clockwise or CounterClockwise
+// Input: points P, Q and R in provided order
+func getOrientation(Px, Py, Qx, Qy, Rx, Ry int) PointsOrientation {
+	val := (Qy-Py)*(Rx-Qx) - (Qx-Px)*(Ry-Qy)
+	if val == 0 {
+		return Collinear
+	}
+	if val > 0 {
+		return Clockwise
+	}
+	return CounterClockwise // if it's neither collinear nor clockwise
+}
+
+// isIntersects Checks if segments intersect each other
+// Input:
+// firstPx, firstPy, firstQx, firstQy === first segment
+// secondPx, secondPy, secondQx, secondQy === second segment
+/*
+Notation
+	P1 = (firstPx, firstPy)
+	Q1 = (firstQx, firstQy)
+	P2 = (secondPx, secondPy)
+	Q2 = (secondQx, secondQy)
+*/
+func isIntersects(firstPx, firstPy, firstQx, firstQy, secondPx, secondPy, secondQx, secondQy int) bool {
+	// Find the four orientations needed for general case and special ones
+	o1 := getOrientation(firstPx, firstPy, firstQx, firstQy, secondPx, secondPy)
+	o2 := getOrientation(firstPx, firstPy, firstQx, firstQy, secondQx, secondQy)
+	o3 := getOrientation(secondPx, secondPy, secondQx, secondQy, firstPx, firstPy)
+	o4 := getOrientation(secondPx, secondPy, secondQx, secondQy, firstQx, firstQy)
+
+	// General case
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+
+	/* Special cases */
+	// P1, Q1, P2 are colinear and P2 lies on segment P1-Q1
+	if o1 == Collinear && isOnSegment(firstPx, firstPy, secondPx, secondPy, firstQx, firstQy) {
+		return true
+	}
+	// P1, Q1 and Q2 are colinear and Q2 lies on segment P1-Q1
+	if o2 == Collinear && isOnSegment(firstPx, firstPy, secondQx, secondQy, firstQx, firstQy) {
+		return true
+	}
+	// P2, Q2 and P1 are colinear and P1 lies on segment P2-Q2
+	if o3 == Collinear && isOnSegment(secondPx, secondPy, firstPx, firstPy, secondQx, secondQy) {
+		return true
+	}
+	// P2, Q2 and Q1 are colinear and Q1 lies on segment P2-Q2
+	if o4 == Collinear && isOnSegment(secondPx, secondPy, firstQx, firstQy, secondQx, secondQy) {
+		return true
+	}
+	// Segments do not intersect
+	return false
+}
@@ -0,0 +1,73 @@
+package odam
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"gocv.io/x/gocv"
+)
+
+var (
+	// Same padding color as in YOLOv5/YOLOv8 preprocessing
+	letterboxColor = color.RGBA{114, 114, 114, 0}
+)
+
+// letterboxTransform Describes how frame is fitted into network's input with keeping aspect ratio
+type letterboxTransform struct {
+	// Ratios between resized frame and original frame. They are derived from rounded size of resized frame, so they could differ a bit for each axis
+	scaleX float32
+	scaleY float32
+	// Size of resized frame (without padding)
+	resizedWidth  int
+	resizedHeight int
+	// Padding (in pixels of network's input) on each side
+	padLeft   int
+	padRight  int
+	padTop    int
+	padBottom int
+	// Size of network's input
+	inputWidth  float32
+	inputHeight float32
+}
+
+// newLetterboxTransform Prepares letterbox transformation for frame of size (frameWidth, frameHeight) and network's input of size (inputWidth, inputHeight)
+func newLetterboxTransform(frameWidth, frameHeight, inputWidth, inputHeight float32) *letterboxTransform {
+	scale := float32(math.Min(float64(inputWidth/frameWidth), float64(inputHeight/frameHeight)))
+	resizedWidth := int(math.Round(float64(frameWidth * scale)))
+	resizedHeight := int(math.Round(float64(frameHeight * scale)))
+	padX := int(inputWidth) - resizedWidth
+	padY := int(inputHeight) - resizedHeight
+	return &letterboxTransform{
+		scaleX:        float32(resizedWidth) / frameWidth,
+		scaleY:        float32(resizedHeight) / frameHeight,
+		resizedWidth:  resizedWidth,
+		resizedHeight: resizedHeight,
+		padLeft:       padX / 2,
+		padRight:      padX - padX/2,
+		padTop:        padY / 2,
+		padBottom:     padY - padY/2,
+		inputWidth:    inputWidth,
+		inputHeight:   inputHeight,
+	}
+}
+
+// apply Resizes image with keeping aspect ratio and pads it to network's input size
+// Notice: caller is responsible for closing of returned gocv.Mat
+func (lb *letterboxTransform) apply(img gocv.Mat) gocv.Mat {
+	resized := gocv.NewMat()
+	defer resized.Close()
+	gocv.Resize(img, &resized, image.Point{X: lb.resizedWidth, Y: lb.resizedHeight}, 0, 0, gocv.InterpolationDefault)
+	padded := gocv.NewMat()
+	gocv.CopyMakeBorder(resized, &padded, lb.padTop, lb.padBottom, lb.padLeft, lb.padRight, gocv.BorderConstant, letterboxColor)
+	return padded
+}
+
+// unproject Maps normalized (relative to network's input) box [cx, cy, w, h] back to frame's pixels
+func (lb *letterboxTransform) unproject(row []float32) (float32, float32, float32, float32) {
+	centerX := (row[0]*lb.inputWidth - float32(lb.padLeft)) / lb.scaleX
+	centerY := (row[1]*lb.inputHeight - float32(lb.padTop)) / lb.scaleY
+	width := row[2] * lb.inputWidth / lb.scaleX
+	height := row[3] * lb.inputHeight / lb.scaleY
+	return centerX, centerY, width, height
+}
@@ -0,0 +1,80 @@
+package odam
+
+import (
+	"image"
+	"testing"
+)
+
+// projectToNetworkInput Maps rectangle in frame's pixels to normalized (relative to network's input) box [cx, cy, w, h]
+func projectToNetworkInput(rect image.Rectangle, lb *letterboxTransform) []float32 {
+	cx := float32(rect.Min.X+rect.Max.X) / 2.0
+	cy := float32(rect.Min.Y+rect.Max.Y) / 2.0
+	return []float32{
+		(cx*lb.scaleX + float32(lb.padLeft)) / lb.inputWidth,
+		(cy*lb.scaleY + float32(lb.padTop)) / lb.inputHeight,
+		float32(rect.Dx()) * lb.scaleX / lb.inputWidth,
+		float32(rect.Dy()) * lb.scaleY / lb.inputHeight,
+	}
+}
+
+func TestLetterboxTransform(t *testing.T) {
+	cases := []struct {
+		frameWidth, frameHeight float32
+		inputWidth, inputHeight float32
+		correctResized          image.Point
+		correctPadding          [4]int // left, right, top, bottom
+	}{
+		{640, 360, 416, 416, image.Point{416, 234}, [4]int{0, 0, 91, 91}},
+		{360, 640, 416, 416, image.Point{234, 416}, [4]int{91, 91, 0, 0}},
+		{1920, 1080, 640, 640, image.Point{640, 360}, [4]int{0, 0, 140, 140}},
+		{416, 416, 416, 416, image.Point{416, 416}, [4]int{0, 0, 0, 0}},
+		{800, 600, 320, 320, image.Point{320, 240}, [4]int{0, 0, 40, 40}},
+		{1280, 720, 640, 384, image.Point{640, 360}, [4]int{0, 0, 12, 12}},
+		{500, 333, 320, 320, image.Point{320, 213}, [4]int{0, 0, 53, 54}},
+	}
+	for i, c := range cases {
+		lb := newLetterboxTransform(c.frameWidth, c.frameHeight, c.inputWidth, c.inputHeight)
+		resized := image.Point{lb.resizedWidth, lb.resizedHeight}
+		if resized != c.correctResized {
+			t.Errorf("#%d Frame %vx%v should be resized to %v for input %vx%v, but got %v", i+1, c.frameWidth, c.frameHeight, c.correctResized, c.inputWidth, c.inputHeight, resized)
+		}
+		padding := [4]int{lb.padLeft, lb.padRight, lb.padTop, lb.padBottom}
+		if padding != c.correctPadding {
+			t.Errorf("#%d Frame %vx%v should be padded by %v for input %vx%v, but got %v", i+1, c.frameWidth, c.frameHeight, c.correctPadding, c.inputWidth, c.inputHeight, padding)
+		}
+	}
+}
+
+func TestLetterboxBoundingBoxRoundTrip(t *testing.T) {
+	cases := []struct {
+		frameWidth, frameHeight float32
+		inputWidth, inputHeight float32
+		rects                   []image.Rectangle
+	}{
+		{640, 360, 416, 416, []image.Rectangle{image.Rect(100, 50, 180, 120), image.Rect(0, 0, 40, 30), image.Rect(560, 300, 640, 360)}},
+		{360, 640, 416, 416, []image.Rectangle{image.Rect(20, 400, 120, 600), image.Rect(300, 10, 360, 70)}},
+		{1920, 1080, 640, 640, []image.Rectangle{image.Rect(900, 500, 1100, 640), image.Rect(10, 1000, 60, 1080)}},
+		{416, 416, 416, 416, []image.Rectangle{image.Rect(200, 200, 260, 240)}},
+		{500, 333, 320, 320, []image.Rectangle{image.Rect(250, 150, 300, 200), image.Rect(0, 280, 50, 333)}},
+	}
+	// Truncation to integer pixels (and float32 precision) could produce 1 pixel error
+	tolerance := 1
+	for i, c := range cases {
+		lb := newLetterboxTransform(c.frameWidth, c.frameHeight, c.inputWidth, c.inputHeight)
+		for _, rect := range c.rects {
+			row := projectToNetworkInput(rect, lb)
+			back := calculateBoundingBox(c.frameWidth, c.frameHeight, row, lb)
+			if absInt(back.Min.X-rect.Min.X) > tolerance || absInt(back.Min.Y-rect.Min.Y) > tolerance ||
+				absInt(back.Max.X-rect.Max.X) > tolerance || absInt(back.Max.Y-rect.Max.Y) > tolerance {
+				t.Errorf("#%d Frame %vx%v, input %vx%v: box %v should be restored after letterbox, but got %v", i+1, c.frameWidth, c.frameHeight, c.inputWidth, c.inputHeight, rect, back)
+			}
+		}
+	}
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,157 @@
+package odam
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gocv.io/x/gocv"
+)
+
+// MODEL_FORMAT Alias to int
+type MODEL_FORMAT int
+
+const (
+	// MODEL_FORMAT_DARKNET Darknet model (*.cfg + *.weights). Output rows are [cx, cy, w, h, objectness, class scores...] with normalized coordinates
+	MODEL_FORMAT_DARKNET = MODEL_FORMAT(iota + 1)
+	// MODEL_FORMAT_YOLOV5_ONNX YOLOv5 exported to ONNX. Output is [1, N, 5 + classes] with rows [cx, cy, w, h, objectness, class scores...] in pixels of network's input
+	MODEL_FORMAT_YOLOV5_ONNX
+	// MODEL_FORMAT_YOLOV8_ONNX YOLOv8 exported to ONNX. Output is [1, 4 + classes, N] with columns [cx, cy, w, h, class scores...] in pixels of network's input. There is no objectness
+	MODEL_FORMAT_YOLOV8_ONNX
+)
+
+// String returns text representation of model format (as it is used in configuration file)
+func (mf MODEL_FORMAT) String() string {
+	switch mf {
+	case MODEL_FORMAT_DARKNET:
+		return "darknet"
+	case MODEL_FORMAT_YOLOV5_ONNX:
+		return "yolov5-onnx"
+	case MODEL_FORMAT_YOLOV8_ONNX:
+		return "yolov8-onnx"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(mf))
+	}
+}
+
+// yoloCandidate Single object candidate extracted from network's output
+type yoloCandidate struct {
+	// [cx, cy, w, h] normalized to [0; 1] relative to network's input
+	box        [4]float32
+	classID    int
+	confidence float32
+}
+
+// outputDecoder Extracts object candidates from single output layer of neural network
+type outputDecoder func(output gocv.Mat, inputWidth, inputHeight float32) ([]yoloCandidate, error)
+
+// getOutputDecoder Returns decoder for provided model format
+func getOutputDecoder(modelFormat MODEL_FORMAT) (outputDecoder, error) {
+	switch modelFormat {
+	case MODEL_FORMAT_DARKNET:
+		return decodeDarknetOutput, nil
+	case MODEL_FORMAT_YOLOV5_ONNX:
+		return decodeYOLOv5Output, nil
+	case MODEL_FORMAT_YOLOV8_ONNX:
+		return decodeYOLOv8Output, nil
+	default:
+		return nil, fmt.Errorf("There is no output decoder for model format '%s'", modelFormat)
+	}
+}
+
+// outputShape Returns number of rows and columns for last two dimensions of output
+// Works for both 2D ([rows, cols]) and 3D ([1, rows, cols]) outputs
+func outputShape(output gocv.Mat) (int, int, error) {
+	dims := output.Size()
+	if len(dims) < 2 {
+		return 0, 0, fmt.Errorf("Output should have at least 2 dimensions, but got %d", len(dims))
+	}
+	for _, d := range dims[:len(dims)-2] {
+		if d != 1 {
+			return 0, 0, fmt.Errorf("Batch size should be 1, but got output of shape %v", dims)
+		}
+	}
+	return dims[len(dims)-2], dims[len(dims)-1], nil
+}
+
+// decodeDarknetOutput See ref. MODEL_FORMAT_DARKNET
+// Notice: OpenCV's region layer multiplies class scores by objectness already
+func decodeDarknetOutput(output gocv.Mat, inputWidth, inputHeight float32) ([]yoloCandidate, error) {
+	rows, cols, err := outputShape(output)
+	if err != nil {
+		return nil, err
+	}
+	if cols < 6 {
+		return nil, fmt.Errorf("Darknet output row should contain at least 6 elements, but got %d", cols)
+	}
+	data, err := output.DataPtrFloat32()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't extract data")
+	}
+	candidates := make([]yoloCandidate, 0, rows)
+	for j := 0; j < rows*cols; j += cols {
+		row := data[j : j+cols]
+		classID, confidence := getClassIDAndConfidence(row[5:])
+		candidates = append(candidates, yoloCandidate{
+			box:        [4]float32{row[0], row[1], row[2], row[3]},
+			classID:    classID,
+			confidence: confidence,
+		})
+	}
+	return candidates, nil
+}
+
+// decodeYOLOv5Output See ref. MODEL_FORMAT_YOLOV5_ONNX
+func decodeYOLOv5Output(output gocv.Mat, inputWidth, inputHeight float32) ([]yoloCandidate, error) {
+	rows, cols, err := outputShape(output)
+	if err != nil {
+		return nil, err
+	}
+	if cols < 6 {
+		return nil, fmt.Errorf("YOLOv5 output row should contain at least 6 elements, but got %d", cols)
+	}
+	data, err := output.DataPtrFloat32()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't extract data")
+	}
+	candidates := make([]yoloCandidate, 0, rows)
+	for j := 0; j < rows*cols; j += cols {
+		row := data[j : j+cols]
+		classID, classScore := getClassIDAndConfidence(row[5:])
+		candidates = append(candidates, yoloCandidate{
+			box:        [4]float32{row[0] / inputWidth, row[1] / inputHeight, row[2] / inputWidth, row[3] / inputHeight},
+			classID:    classID,
+			confidence: row[4] * classScore,
+		})
+	}
+	return candidates, nil
+}
+
+// decodeYOLOv8Output See ref. MODEL_FORMAT_YOLOV8_ONNX
+func decodeYOLOv8Output(output gocv.Mat, inputWidth, inputHeight float32) ([]yoloCandidate, error) {
+	attributes, n, err := outputShape(output)
+	if err != nil {
+		return nil, err
+	}
+	if attributes < 5 {
+		return nil, fmt.Errorf("YOLOv8 output should contain at least 5 attributes for each candidate, but got %d", attributes)
+	}
+	data, err := output.DataPtrFloat32()
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't extract data")
+	}
+	candidates := make([]yoloCandidate, 0, n)
+	scores := make([]float32, attributes-4)
+	for i := 0; i < n; i++ {
+		// Output is transposed: each attribute is stored as contiguous array of N values
+		for c := range scores {
+			scores[c] = data[(c+4)*n+i]
+		}
+		classID, confidence := getClassIDAndConfidence(scores)
+		candidates = append(candidates, yoloCandidate{
+			box:        [4]float32{data[i] / inputWidth, data[n+i] / inputHeight, data[2*n+i] / inputWidth, data[3*n+i] / inputHeight},
+			classID:    classID,
+			confidence: confidence,
+		})
+	}
+	return candidates, nil
+}
@@ -0,0 +1,284 @@
+package odam
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// pipelineFrame Single frame travelling through the pipeline stages
+// Each stage owns the frame while processing it. Frame is closed either by sinks or when it is dropped
+type pipelineFrame struct {
+	frame *FrameData
+	// Timestamp of the frame (based on position in video stream)
+	timestamp time.Time
+	// Filled by detector stage
+	detected DetectedObjects
+	// Filled by tracker stage
+	lineEvents      []*lineCrossingEvent
+	polygonEvents   []*polygonEvent
+	violationEvents []*speedViolationEvent
+}
+
+// close Free memory for underlying frame
+func (pf *pipelineFrame) close() {
+	pf.frame.Close()
+}
+
+// objectEvent Information about object at the moment of event
+// It is collected by tracker stage, since blob could be changed by the time analytics stage handles the event
+type objectEvent struct {
+	timestamp int64
+	rect      image.Rectangle
+	class     *ClassInfo
+	track     *TrackInfo
+}
+
+// lineCrossingEvent Information about object which has crossed virtual line
+type lineCrossingEvent struct {
+	objectEvent
+	line      *LinesSetting
+	direction LINE_DIRECTION
+}
+
+// polygonEvent Information about object which has entered or left virtual polygon
+type polygonEvent struct {
+	objectEvent
+	polygon   *PolygonsSetting
+	eventType PolygonEventType
+	// Stay of object in polygon. Could be empty when object has left polygon before it has been registered as entered
+	visit PolygonVisit
+}
+
+// speedViolationEvent Information about object which has exceeded speed limit
+type speedViolationEvent struct {
+	objectEvent
+	limit float32
+	// Limit is defined for polygon rather than for class of object
+	zoneLimit bool
+	polygonID int64
+	// Estimations of speed which have triggered violation
+	records []SpeedRecord
+}
+
+// frameQueue Bounded queue between two pipeline stages
+type frameQueue struct {
+	// Number of dropped frames. Keep it first for 64-bit alignment of atomic operations
+	dropped    uint64
+	name       string
+	items      chan *pipelineFrame
+	dropPolicy DROP_POLICY
+}
+
+// newFrameQueue Constructor for frameQueue
+//
+// name - name of stage which produces frames (for logging purposes)
+// settings - settings of stage
+//
+func newFrameQueue(name string, settings *StageSettings) *frameQueue {
+	return &frameQueue{
+		name:       name,
+		items:      make(chan *pipelineFrame, settings.BufferSize),
+		dropPolicy: settings.DropPolicyType,
+	}
+}
+
+// push Puts frame to the queue according to drop policy
+// Returns false if pipeline has been stopped: frame is closed then
+// Notice: there should be the only one producer for the queue
+func (q *frameQueue) push(ctx context.Context, pf *pipelineFrame) bool {
+	if q.dropPolicy != DROP_POLICY_DROP_OLDEST {
+		select {
+		case q.items <- pf:
+			return true
+		case <-ctx.Done():
+			pf.close()
+			return false
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			pf.close()
+			return false
+		case q.items <- pf:
+			return true
+		default:
+		}
+		// Queue is full: give place for new frame
+		select {
+		case oldest := <-q.items:
+			oldest.close()
+			atomic.AddUint64(&q.dropped, 1)
+		default:
+		}
+	}
+}
+
+// drain Closes all frames left in the queue
+// Notice: should be called only when producer of the queue has stopped
+func (q *frameQueue) drain() {
+	for pf := range q.items {
+		pf.close()
+	}
+}
+
+// droppedCount Returns number of dropped frames
+func (q *frameQueue) droppedCount() uint64 {
+	return atomic.LoadUint64(&q.dropped)
+}
+
+// pipeline Stages connected by bounded queues: grabber → preprocess → detector → tracker → analytics → sinks
+// Sinks are not started by pipeline: caller reads frames via output()
+type pipeline struct {
+	app     *Application
+	queues  []*frameQueue
+	running sync.WaitGroup
+}
+
+// newPipeline Constructor for pipeline
+func newPipeline(app *Application) *pipeline {
+	settings := &app.settings.PipelineSettings
+	return &pipeline{
+		app: app,
+		queues: []*frameQueue{
+			newFrameQueue("grabber", &settings.Grabber),
+			newFrameQueue("preprocess", &settings.Preprocess),
+			newFrameQueue("detector", &settings.Detector),
+			newFrameQueue("tracker", &settings.Tracker),
+			newFrameQueue("analytics", &settings.Analytics),
+		},
+	}
+}
+
+// start Starts all stages in separate goroutines
+//
+// ctx - when context is done every stage stops as soon as possible
+// videoCapturer - source of frames
+//
+func (p *pipeline) start(ctx context.Context, videoCapturer *gocv.VideoCapture) {
+	app := p.app
+	settings := app.settings
+	p.running.Add(len(p.queues))
+	go p.runGrabber(ctx, videoCapturer, p.queues[0])
+	undistorter := app.frameUndistorter()
+	go p.runStage(ctx, p.queues[0], p.queues[1], func(pf *pipelineFrame) bool {
+		err := pf.frame.Preprocess(settings.VideoSettings.ReducedWidth, settings.VideoSettings.ReducedHeight, undistorter)
+		if err != nil {
+			fmt.Printf("Can't preprocess. Error: %s. Skipping frame\n", err.Error())
+			return false
+		}
+		return true
+	})
+	go p.runStage(ctx, p.queues[1], p.queues[2], func(pf *pipelineFrame) bool {
+		pf.detected = app.performDetectionSequential(pf.frame)
+		return true
+	})
+	// Time difference is evaluated between frames which have reached the tracker (some of them could be dropped before)
+	var lastTime time.Time
+	go p.runStage(ctx, p.queues[2], p.queues[3], func(pf *pipelineFrame) bool {
+		secDiff := 0.0
+		if !lastTime.IsZero() {
+			secDiff = pf.timestamp.Sub(lastTime).Seconds()
+		}
+		lastTime = pf.timestamp
+		app.trackObjects(pf, secDiff)
+		return true
+	})
+	go p.runStage(ctx, p.queues[3], p.queues[4], func(pf *pipelineFrame) bool {
+		app.analyzeFrame(pf)
+		return true
+	})
+}
+
+// output Returns frames which have passed through all stages. Channel is closed when pipeline stops
+func (p *pipeline) output() <-chan *pipelineFrame {
+	return p.queues[len(p.queues)-1].items
+}
+
+// wait Waits until all stages stop and frees frames left in queues
+// Notice: context passed to start() should be done or video stream should be finished, otherwise it blocks forever
+func (p *pipeline) wait() {
+	p.running.Wait()
+	for _, q := range p.queues {
+		q.drain()
+		if dropped := q.droppedCount(); dropped > 0 {
+			fmt.Printf("Stage '%s' has dropped %d frames\n", q.name, dropped)
+		}
+	}
+}
+
+// runGrabber Reads frames from video source and passes them to output queue
+func (p *pipeline) runGrabber(ctx context.Context, videoCapturer *gocv.VideoCapture, out *frameQueue) {
+	defer p.running.Done()
+	defer close(out.items)
+	/* Initialize variables for evaluation of time difference between frames */
+	lastMS := 0.0
+	lastTime := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		frame := NewFrameData()
+		// Grab a frame
+		if ok := videoCapturer.Read(&frame.ImgSource); !ok {
+			fmt.Println("Can't read next frame, stop grabbing...")
+			frame.Close()
+			return
+		}
+		/* Evaluate timestamp */
+		currentMS := videoCapturer.Get(gocv.VideoCapturePosMsec)
+		msDiff := currentMS - lastMS
+		lastTime = lastTime.Add(time.Duration(msDiff) * time.Millisecond)
+		lastMS = currentMS
+
+		/* Skip empty frame */
+		if frame.ImgSource.Empty() {
+			frame.Close()
+			fmt.Println("Empty frame has been detected. Sleep for 400 ms")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(400 * time.Millisecond):
+			}
+			continue
+		}
+		if !out.push(ctx, &pipelineFrame{frame: frame, timestamp: lastTime}) {
+			return
+		}
+	}
+}
+
+// runStage Reads frames from input queue, processes them and passes them to output queue
+// Output queue is closed when input queue is closed or context is done
+//
+// process - processing function. It should return false if frame should not go further: frame is closed then
+//
+func (p *pipeline) runStage(ctx context.Context, in, out *frameQueue, process func(pf *pipelineFrame) bool) {
+	defer p.running.Done()
+	defer close(out.items)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pf, ok := <-in.items:
+			if !ok {
+				return
+			}
+			if !process(pf) {
+				pf.close()
+				continue
+			}
+			if !out.push(ctx, pf) {
+				return
+			}
+		}
+	}
+}
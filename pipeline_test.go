@@ -0,0 +1,95 @@
+package odam
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFrameQueueDropOldest(t *testing.T) {
+	q := newFrameQueue("test", &StageSettings{BufferSize: 2, DropPolicyType: DROP_POLICY_DROP_OLDEST})
+	ctx := context.Background()
+	baseTime := time.Unix(0, 0)
+	for i := 0; i < 5; i++ {
+		if !q.push(ctx, &pipelineFrame{frame: NewFrameData(), timestamp: baseTime.Add(time.Duration(i) * time.Second)}) {
+			t.Errorf("Frame #%d should be pushed", i)
+		}
+	}
+	if q.droppedCount() != 3 {
+		t.Errorf("Should be 3 dropped frames, but got %d", q.droppedCount())
+	}
+	close(q.items)
+	// The most recent frames should be kept
+	expected := []time.Time{baseTime.Add(3 * time.Second), baseTime.Add(4 * time.Second)}
+	i := 0
+	for pf := range q.items {
+		if i >= len(expected) {
+			t.Errorf("Unexpected frame with timestamp %v", pf.timestamp)
+		} else if !pf.timestamp.Equal(expected[i]) {
+			t.Errorf("Frame #%d should have timestamp %v, but got %v", i, expected[i], pf.timestamp)
+		}
+		pf.close()
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("Should be %d frames in queue, but got %d", len(expected), i)
+	}
+}
+
+func TestFrameQueueBlock(t *testing.T) {
+	q := newFrameQueue("test", &StageSettings{BufferSize: 1, DropPolicyType: DROP_POLICY_BLOCK})
+	ctx, cancel := context.WithCancel(context.Background())
+	if !q.push(ctx, &pipelineFrame{frame: NewFrameData()}) {
+		t.Errorf("First frame should be pushed")
+	}
+	// Queue is full: push should wait until context is done
+	pushed := make(chan bool)
+	go func() {
+		pushed <- q.push(ctx, &pipelineFrame{frame: NewFrameData()})
+	}()
+	select {
+	case <-pushed:
+		t.Errorf("Push should block when queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	select {
+	case ok := <-pushed:
+		if ok {
+			t.Errorf("Push should fail when context is done")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Push should be unblocked when context is done")
+	}
+	if q.droppedCount() != 0 {
+		t.Errorf("There should be no dropped frames, but got %d", q.droppedCount())
+	}
+	close(q.items)
+	q.drain()
+}
+
+func TestPipelineSettings(t *testing.T) {
+	ps := PipelineSettings{
+		Grabber: StageSettings{BufferSize: 4, DropPolicy: "DROP_OLDEST"},
+		Tracker: StageSettings{DropPolicy: "drop_oldest"},
+	}
+	err := ps.Prepare()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ps.Grabber.BufferSize != 4 || ps.Grabber.DropPolicyType != DROP_POLICY_DROP_OLDEST {
+		t.Errorf("Grabber stage should have buffer size 4 and policy 'drop_oldest', but got %d and '%s'", ps.Grabber.BufferSize, ps.Grabber.DropPolicyType)
+	}
+	if ps.Detector.BufferSize != defaultStageBufferSize || ps.Detector.DropPolicyType != DROP_POLICY_BLOCK {
+		t.Errorf("Detector stage should have default buffer size %d and policy 'block', but got %d and '%s'", defaultStageBufferSize, ps.Detector.BufferSize, ps.Detector.DropPolicyType)
+	}
+	// Frames after tracker carry events, so they should never be dropped
+	if ps.Tracker.DropPolicyType != DROP_POLICY_BLOCK {
+		t.Errorf("Tracker stage should have policy 'block', but got '%s'", ps.Tracker.DropPolicyType)
+	}
+	wrong := PipelineSettings{Analytics: StageSettings{DropPolicy: "drop_newest"}}
+	if err := wrong.Prepare(); err == nil {
+		t.Errorf("Unsupported drop policy should cause an error")
+	}
+}
@@ -0,0 +1,61 @@
+package odam
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	defaultAggregationIntervals = []int{60, 300, 900}
+)
+
+// AggregationSettings Settings for in-process aggregation of events for each virtual line and virtual polygon
+type AggregationSettings struct {
+	Enabled bool `json:"enabled"`
+	// Durations of time buckets (in seconds). Default is [60, 300, 900] (1 min, 5 min, 15 min)
+	Intervals []int `json:"intervals"`
+	// Path to file for summaries (JSON lines). If it is not provided then summaries are passed to handler set via Aggregator.SetHandler() only
+	File string `json:"file"`
+
+	// Exported, but not from JSON
+	IntervalsDurations []time.Duration `json:"-"`
+}
+
+// Prepare Prepares this structure for further usage
+func (as *AggregationSettings) Prepare() {
+	if !as.Enabled {
+		return
+	}
+	if len(as.Intervals) == 0 {
+		as.Intervals = make([]int, len(defaultAggregationIntervals))
+		copy(as.Intervals, defaultAggregationIntervals)
+	}
+	unique := make(map[int]struct{}, len(as.Intervals))
+	intervals := make([]int, 0, len(as.Intervals))
+	for _, interval := range as.Intervals {
+		if interval <= 0 {
+			fmt.Printf("[WARNING] Field 'intervals' in 'aggregation_settings' should contain only positive values, but got '%d'. Skipping it\n", interval)
+			continue
+		}
+		if _, ok := unique[interval]; ok {
+			continue
+		}
+		unique[interval] = struct{}{}
+		intervals = append(intervals, interval)
+	}
+	if len(intervals) == 0 {
+		fmt.Println("[WARNING] Field 'intervals' in 'aggregation_settings' has no valid values. Disabling aggregation feature...")
+		as.Enabled = false
+		return
+	}
+	if as.File == "" {
+		fmt.Println("[WARNING] Field 'file' in 'aggregation_settings' has not been provided. Summaries will be passed only to handler set via Aggregator.SetHandler()...")
+	}
+	sort.Ints(intervals)
+	as.Intervals = intervals
+	as.IntervalsDurations = make([]time.Duration, len(intervals))
+	for i, interval := range intervals {
+		as.IntervalsDurations[i] = time.Duration(interval) * time.Second
+	}
+}
@@ -0,0 +1,88 @@
+package odam
+
+import (
+	"fmt"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+// InferenceSettings Backend and target device for neural network inference
+type InferenceSettings struct {
+	// Possible values are: default, opencv, cuda, openvino
+	Backend string `json:"backend"`
+	// Possible values are: cpu, opencl, opencl_fp16, cuda, cuda_fp16
+	Target string `json:"target"`
+
+	// Exported, but not from JSON
+	BackendType gocv.NetBackendType `json:"-"`
+	TargetType  gocv.NetTargetType  `json:"-"`
+}
+
+var (
+	inferenceBackends = map[string]gocv.NetBackendType{
+		"default":  gocv.NetBackendDefault,
+		"opencv":   gocv.NetBackendOpenCV,
+		"cuda":     gocv.NetBackendCUDA,
+		"openvino": gocv.NetBackendOpenVINO,
+	}
+	inferenceTargets = map[string]gocv.NetTargetType{
+		"cpu":         gocv.NetTargetCPU,
+		"opencl":      gocv.NetTargetFP32,
+		"opencl_fp16": gocv.NetTargetFP16,
+		"cuda":        gocv.NetTargetCUDA,
+		"cuda_fp16":   gocv.NetTargetCUDAFP16,
+	}
+	// Targets which could be used with certain backend
+	inferenceSupportedTargets = map[string][]string{
+		"default":  {"cpu", "opencl", "opencl_fp16"},
+		"opencv":   {"cpu", "opencl", "opencl_fp16"},
+		"cuda":     {"cuda", "cuda_fp16"},
+		"openvino": {"cpu", "opencl", "opencl_fp16"},
+	}
+)
+
+// Prepare Prepares this structure for further usage
+//
+// cudaEnabled - value of 'enable' field in 'cuda_settings'. When CUDA is disabled CPU is used as fallback for CUDA backend and targets
+//
+func (is *InferenceSettings) Prepare(cudaEnabled bool) error {
+	is.Backend = strings.ToLower(is.Backend)
+	is.Target = strings.ToLower(is.Target)
+	if is.Backend == "" {
+		if cudaEnabled {
+			is.Backend = "cuda"
+		} else {
+			is.Backend = "default"
+		}
+		fmt.Printf("[WARNING] Field 'backend' in 'inference_settings' has not been provided. Using default value = '%s'\n", is.Backend)
+	}
+	if is.Target == "" {
+		if is.Backend == "cuda" {
+			is.Target = "cuda"
+		} else {
+			is.Target = "cpu"
+		}
+		fmt.Printf("[WARNING] Field 'target' in 'inference_settings' has not been provided. Using default value = '%s'\n", is.Target)
+	}
+	if !cudaEnabled && (is.Backend == "cuda" || strings.HasPrefix(is.Target, "cuda")) {
+		fmt.Printf("[WARNING] CUDA is disabled in 'cuda_settings', but backend = '%s' and target = '%s' have been provided in 'inference_settings'. Using fallback: backend = 'default', target = 'cpu'\n", is.Backend, is.Target)
+		is.Backend = "default"
+		is.Target = "cpu"
+	}
+	backendType, ok := inferenceBackends[is.Backend]
+	if !ok {
+		return fmt.Errorf("Value '%s' of field 'backend' in 'inference_settings' is not supported. Possible values are: default, opencv, cuda, openvino", is.Backend)
+	}
+	targetType, ok := inferenceTargets[is.Target]
+	if !ok {
+		return fmt.Errorf("Value '%s' of field 'target' in 'inference_settings' is not supported. Possible values are: cpu, opencl, opencl_fp16, cuda, cuda_fp16", is.Target)
+	}
+	supportedTargets := inferenceSupportedTargets[is.Backend]
+	if !stringInSlice(&is.Target, supportedTargets) {
+		return fmt.Errorf("Target '%s' can't be used with backend '%s' in 'inference_settings'. Supported targets for this backend are: %s", is.Target, is.Backend, strings.Join(supportedTargets, ", "))
+	}
+	is.BackendType = backendType
+	is.TargetType = targetType
+	return nil
+}
@@ -0,0 +1,47 @@
+package odam
+
+import (
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+func TestInferenceSettings(t *testing.T) {
+	cases := []struct {
+		settings        InferenceSettings
+		cudaEnabled     bool
+		correctBackend  gocv.NetBackendType
+		correctTarget   gocv.NetTargetType
+		shouldBeFailure bool
+	}{
+		{InferenceSettings{}, true, gocv.NetBackendCUDA, gocv.NetTargetCUDA, false},
+		{InferenceSettings{}, false, gocv.NetBackendDefault, gocv.NetTargetCPU, false},
+		{InferenceSettings{Backend: "cuda", Target: "cuda_fp16"}, true, gocv.NetBackendCUDA, gocv.NetTargetCUDAFP16, false},
+		{InferenceSettings{Backend: "cuda", Target: "cuda"}, false, gocv.NetBackendDefault, gocv.NetTargetCPU, false},
+		{InferenceSettings{Backend: "OpenCV", Target: "OpenCL_FP16"}, false, gocv.NetBackendOpenCV, gocv.NetTargetFP16, false},
+		{InferenceSettings{Backend: "openvino", Target: "opencl"}, false, gocv.NetBackendOpenVINO, gocv.NetTargetFP32, false},
+		{InferenceSettings{Backend: "cuda", Target: "cpu"}, true, 0, 0, true},
+		{InferenceSettings{Backend: "opencv", Target: "cuda"}, true, 0, 0, true},
+		{InferenceSettings{Backend: "halide", Target: "cpu"}, true, 0, 0, true},
+		{InferenceSettings{Backend: "default", Target: "vulkan"}, true, 0, 0, true},
+	}
+	for i, c := range cases {
+		err := c.settings.Prepare(c.cudaEnabled)
+		if c.shouldBeFailure {
+			if err == nil {
+				t.Errorf("#%d Backend '%s' with target '%s' should not be supported", i+1, c.settings.Backend, c.settings.Target)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d Unexpected error: %s", i+1, err.Error())
+			continue
+		}
+		if c.settings.BackendType != c.correctBackend {
+			t.Errorf("#%d Backend should be %d, but got %d", i+1, c.correctBackend, c.settings.BackendType)
+		}
+		if c.settings.TargetType != c.correctTarget {
+			t.Errorf("#%d Target should be %d, but got %d", i+1, c.correctTarget, c.settings.TargetType)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package odam
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultInputWidth      = 608
+	defaultInputHeight     = 608
+	defaultONNXInputWidth  = 640
+	defaultONNXInputHeight = 640
+	defaultScaleFactor     = 1.0 / 255.0
+)
+
+// NeuralNetworkSettings Neural network
+type NeuralNetworkSettings struct {
+	// Possible values are: darknet, yolov5-onnx, yolov8-onnx. Default is 'darknet'
+	ModelFormat string `json:"model_format"`
+	// Path to *.onnx file. Used for 'yolov5-onnx' and 'yolov8-onnx' model formats only
+	ONNXModel      string  `json:"onnx_model"`
+	DarknetCFG     string  `json:"darknet_cfg"`
+	DarknetWeights string  `json:"darknet_weights"`
+	DarknetClasses string  `json:"darknet_classes"`
+	ConfThreshold  float64 `json:"conf_threshold"`
+	NmsThreshold   float64 `json:"nms_threshold"`
+	// Width and height of network's input. If those are not provided (or <= 0) then values from [net] section of 'darknet_cfg' file are used
+	InputWidth  int `json:"input_width"`
+	InputHeight int `json:"input_height"`
+	// Multiplier for image values. Default is 1/255
+	ScaleFactor float64 `json:"scale_factor"`
+	// Mean values which are subtracted from each channel. Default is [0, 0, 0]
+	Mean [3]float64 `json:"mean"`
+	// Should first and last channels be swapped (BGR -> RGB). Default is true
+	SwapRB *bool `json:"swap_rb"`
+	// Should image be resized with keeping aspect ratio and padded to network's input size. Default is false (image is stretched)
+	Letterbox bool `json:"letterbox"`
+	// Exported, but not from JSON
+	NetClasses    []string `json:"-"`
+	TargetClasses []string `json:"target_classes"`
+	// Exported, but not from JSON
+	// Confidence thresholds for certain classes (prepared from 'classes_settings')
+	ClassesThresholds map[string]float32 `json:"-"`
+	// Exported, but not from JSON
+	ModelFormatType MODEL_FORMAT `json:"-"`
+}
+
+// Prepare Prepares this structure for further usage
+//
+// classesSettings - settings for each class. Used for extracting per-class confidence thresholds
+//
+func (nns *NeuralNetworkSettings) Prepare(classesSettings []*ClassesSettings) error {
+	switch strings.ToLower(nns.ModelFormat) {
+	case "darknet":
+		nns.ModelFormatType = MODEL_FORMAT_DARKNET
+		break
+	case "yolov5-onnx":
+		nns.ModelFormatType = MODEL_FORMAT_YOLOV5_ONNX
+		break
+	case "yolov8-onnx":
+		nns.ModelFormatType = MODEL_FORMAT_YOLOV8_ONNX
+		break
+	case "":
+		fmt.Println("[WARNING] Field 'model_format' in 'neural_network_settings' is empty. Using default value 'darknet'")
+		nns.ModelFormatType = MODEL_FORMAT_DARKNET
+		break
+	default:
+		return fmt.Errorf("Value '%s' of field 'model_format' in 'neural_network_settings' is not supported. Possible values are: darknet, yolov5-onnx, yolov8-onnx", nns.ModelFormat)
+	}
+	nns.ModelFormat = nns.ModelFormatType.String()
+	if nns.ModelFormatType != MODEL_FORMAT_DARKNET && nns.ONNXModel == "" {
+		return fmt.Errorf("Field 'onnx_model' in 'neural_network_settings' should be provided for model format '%s'", nns.ModelFormat)
+	}
+	if nns.ConfThreshold <= 0 || nns.ConfThreshold >= 1 {
+		fmt.Printf("[WARNING] Field 'conf_threshold' in 'neural_network_settings' should be in (0;1), but got '%f'. Using default value = 0.5\n", nns.ConfThreshold)
+		nns.ConfThreshold = 0.5
+	}
+	if nns.NmsThreshold <= 0 || nns.NmsThreshold >= 1 {
+		fmt.Printf("[WARNING] Field 'nms_threshold' in 'neural_network_settings' should be in (0;1), but got '%f'. Using default value = 0.4\n", nns.NmsThreshold)
+		nns.NmsThreshold = 0.4
+	}
+	nns.ClassesThresholds = make(map[string]float32)
+	for _, classInfo := range classesSettings {
+		if classInfo.ConfThreshold <= 0 {
+			// Default threshold will be used
+			continue
+		}
+		if classInfo.ConfThreshold >= 1 {
+			fmt.Printf("[WARNING] Field 'conf_threshold' for class '%s' should be in (0;1), but got '%f'. Using value from 'neural_network_settings' = %f\n", classInfo.ClassName, classInfo.ConfThreshold, nns.ConfThreshold)
+			continue
+		}
+		nns.ClassesThresholds[classInfo.ClassName] = float32(classInfo.ConfThreshold)
+	}
+	if nns.InputWidth <= 0 || nns.InputHeight <= 0 {
+		var width, height int
+		if nns.ModelFormatType == MODEL_FORMAT_DARKNET {
+			var err error
+			width, height, err = ReadDarknetInputSize(nns.DarknetCFG)
+			if err != nil {
+				fmt.Printf("[WARNING] Fields 'input_width' and 'input_height' in 'neural_network_settings' have not been provided (or <=0) and they can't be extracted from '%s' due the error: %s. Using default %dx%d size\n", nns.DarknetCFG, err.Error(), defaultInputWidth, defaultInputHeight)
+				width, height = defaultInputWidth, defaultInputHeight
+			}
+		} else {
+			fmt.Printf("[WARNING] Fields 'input_width' and 'input_height' in 'neural_network_settings' have not been provided (or <=0). Using default %dx%d size for ONNX models\n", defaultONNXInputWidth, defaultONNXInputHeight)
+			width, height = defaultONNXInputWidth, defaultONNXInputHeight
+		}
+		if nns.InputWidth <= 0 {
+			nns.InputWidth = width
+		}
+		if nns.InputHeight <= 0 {
+			nns.InputHeight = height
+		}
+	}
+	if nns.ScaleFactor <= 0 {
+		nns.ScaleFactor = defaultScaleFactor
+	}
+	if nns.SwapRB == nil {
+		swapRB := true
+		nns.SwapRB = &swapRB
+	}
+	return nil
+}
+
+// ReadDarknetInputSize Extracts width and height of network's input from [net] section of Darknet's configuration file
+func ReadDarknetInputSize(fname string) (int, int, error) {
+	cfgFile, err := os.Open(fname)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cfgFile.Close()
+	return readDarknetInputSize(cfgFile)
+}
+
+// readDarknetInputSize See ref. ReadDarknetInputSize
+func readDarknetInputSize(r io.Reader) (int, int, error) {
+	var err error
+	width, height := 0, 0
+	inNetSection := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if idx := strings.IndexAny(line, "#;"); idx >= 0 {
+			// Eliminate comments
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if inNetSection {
+				// [net] section is over
+				break
+			}
+			inNetSection = line == "[net]" || line == "[network]"
+			continue
+		}
+		if !inNetSection {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "width":
+			width, err = strconv.Atoi(value)
+			if err != nil {
+				return 0, 0, errors.Wrapf(err, "Can't parse 'width' value '%s'", value)
+			}
+			break
+		case "height":
+			height, err = strconv.Atoi(value)
+			if err != nil {
+				return 0, 0, errors.Wrapf(err, "Can't parse 'height' value '%s'", value)
+			}
+			break
+		default:
+			break
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if width <= 0 || height <= 0 {
+		return 0, 0, fmt.Errorf("Positive 'width' and 'height' have not been found in [net] section")
+	}
+	return width, height, nil
+}
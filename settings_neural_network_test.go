@@ -0,0 +1,49 @@
+package odam
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadDarknetInputSize(t *testing.T) {
+	width, height, err := ReadDarknetInputSize("cmd/odam/yolov3.cfg")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if width != 416 || height != 416 {
+		t.Errorf("Input size should be 416x416, but got %dx%d", width, height)
+	}
+
+	cfgs := []string{
+		"[net]\n# width=608\nwidth = 320 # custom model\nheight=256\n[convolutional]\nwidth=1\n",
+		"[net]\nbatch=1\n\n[convolutional]\nwidth=416\nheight=416\n",
+		"[net]\nwidth=abc\nheight=416\n",
+	}
+	correctAnswers := [][2]int{
+		{320, 256},
+		{0, 0},
+		{0, 0},
+	}
+	shouldBeFailure := []bool{
+		false,
+		true,
+		true,
+	}
+	for i, cfg := range cfgs {
+		width, height, err := readDarknetInputSize(strings.NewReader(cfg))
+		if shouldBeFailure[i] {
+			if err == nil {
+				t.Errorf("#%d Reading input size should fail, but got %dx%d", i+1, width, height)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d Unexpected error: %s", i+1, err.Error())
+			continue
+		}
+		if width != correctAnswers[i][0] || height != correctAnswers[i][1] {
+			t.Errorf("#%d Input size should be %dx%d, but got %dx%d", i+1, correctAnswers[i][0], correctAnswers[i][1], width, height)
+		}
+	}
+}
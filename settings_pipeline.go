@@ -0,0 +1,106 @@
+package odam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DROP_POLICY Alias to int
+type DROP_POLICY int
+
+const (
+	// DROP_POLICY_BLOCK Stage waits until next stage takes frame from the queue. No frames are lost, but slow stage slows down all previous ones
+	DROP_POLICY_BLOCK = DROP_POLICY(iota + 1)
+	// DROP_POLICY_DROP_OLDEST Oldest frame in the queue is dropped in favor of new one. Useful for live streams, since pipeline always works with the most recent frames
+	DROP_POLICY_DROP_OLDEST
+)
+
+// String returns text representation of drop policy (as it is used in configuration file)
+func (dp DROP_POLICY) String() string {
+	switch dp {
+	case DROP_POLICY_BLOCK:
+		return "block"
+	case DROP_POLICY_DROP_OLDEST:
+		return "drop_oldest"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(dp))
+	}
+}
+
+const (
+	defaultStageBufferSize = 1
+)
+
+// StageSettings Settings for output queue of single pipeline stage
+type StageSettings struct {
+	// Maximum number of frames waiting for the next stage
+	BufferSize int `json:"buffer_size"`
+	// Possible values are: block, drop_oldest
+	DropPolicy string `json:"drop_policy"`
+
+	// Exported, but not from JSON
+	DropPolicyType DROP_POLICY `json:"-"`
+}
+
+// PipelineSettings Settings for output queues of pipeline stages: grabber → preprocess → detector → tracker → analytics → sinks
+type PipelineSettings struct {
+	Grabber    StageSettings `json:"grabber"`
+	Preprocess StageSettings `json:"preprocess"`
+	Detector   StageSettings `json:"detector"`
+	Tracker    StageSettings `json:"tracker"`
+	Analytics  StageSettings `json:"analytics"`
+}
+
+// Prepare Prepares this structure for further usage
+func (ps *PipelineSettings) Prepare() error {
+	stages := []struct {
+		name     string
+		settings *StageSettings
+	}{
+		{"grabber", &ps.Grabber},
+		{"preprocess", &ps.Preprocess},
+		{"detector", &ps.Detector},
+		{"tracker", &ps.Tracker},
+		{"analytics", &ps.Analytics},
+	}
+	for _, stage := range stages {
+		err := stage.settings.prepare(stage.name)
+		if err != nil {
+			return err
+		}
+	}
+	// Frames after tracker stage carry events (e.g. virtual line crossings): dropping them means losing events
+	if ps.Tracker.DropPolicyType == DROP_POLICY_DROP_OLDEST {
+		fmt.Println("[WARNING] Field 'drop_policy' for stage 'tracker' in 'pipeline_settings' can't be 'drop_oldest', since frames after tracking carry events. Using 'block'")
+		ps.Tracker.DropPolicy = DROP_POLICY_BLOCK.String()
+		ps.Tracker.DropPolicyType = DROP_POLICY_BLOCK
+	}
+	return nil
+}
+
+// prepare Prepares settings of single stage
+//
+// stageName - name of stage (for logging purposes)
+//
+func (ss *StageSettings) prepare(stageName string) error {
+	if ss.BufferSize < 0 {
+		fmt.Printf("[WARNING] Field 'buffer_size' for stage '%s' in 'pipeline_settings' should be > 0, but got '%d'. Using default value = %d\n", stageName, ss.BufferSize, defaultStageBufferSize)
+	}
+	if ss.BufferSize <= 0 {
+		ss.BufferSize = defaultStageBufferSize
+	}
+	ss.DropPolicy = strings.ToLower(ss.DropPolicy)
+	switch ss.DropPolicy {
+	case "block":
+		ss.DropPolicyType = DROP_POLICY_BLOCK
+	case "drop_oldest":
+		ss.DropPolicyType = DROP_POLICY_DROP_OLDEST
+	case "":
+		// Keep behaviour of sequential processing by default: no frames are lost
+		ss.DropPolicy = "block"
+		ss.DropPolicyType = DROP_POLICY_BLOCK
+	default:
+		return fmt.Errorf("Value '%s' of field 'drop_policy' for stage '%s' in 'pipeline_settings' is not supported. Possible values are: block, drop_oldest", ss.DropPolicy, stageName)
+	}
+	return nil
+}
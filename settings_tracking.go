@@ -54,11 +54,40 @@ func (trs *TrackerSettings) Prepare() {
 	if len(trs.LinesSettings) == 0 {
 		fmt.Println("[WARNING] No 'lines_settings'? Please check if it is true")
 	}
+	linesIDs := make(map[int64]struct{}, len(trs.LinesSettings))
 	for _, lsettings := range trs.LinesSettings {
-		vline := NewVirtualLine(lsettings.Begin[0], lsettings.Begin[1], lsettings.End[0], lsettings.End[1])
+		if _, ok := linesIDs[lsettings.LineID]; ok {
+			fmt.Printf("[WARNING] Field 'line_id' = '%d' is used for several lines. Object crossing one of them won't be counted by others\n", lsettings.LineID)
+		}
+		linesIDs[lsettings.LineID] = struct{}{}
+		var vline *VirtualLine
+		if len(lsettings.Points) >= 2 {
+			points := make([]image.Point, len(lsettings.Points))
+			for i, pair := range lsettings.Points {
+				points[i] = image.Point{X: pair[0], Y: pair[1]}
+			}
+			vline = NewVirtualPolyline(points...)
+		} else {
+			if len(lsettings.Points) != 0 {
+				fmt.Printf("[WARNING] Field 'points' for line (id = '%d') should contain at least 2 points. Using 'begin' and 'end' fields\n", lsettings.LineID)
+			}
+			vline = NewVirtualLine(lsettings.Begin[0], lsettings.Begin[1], lsettings.End[0], lsettings.End[1])
+		}
 		vline.Color = color.RGBA{lsettings.RGBA[0], lsettings.RGBA[1], lsettings.RGBA[2], lsettings.RGBA[3]}
-		if lsettings.Direction == "from_detector" {
+		switch lsettings.Direction {
+		case "to_detector":
+			vline.Direction = true
+			break
+		case "from_detector":
 			vline.Direction = false
+			break
+		case "both":
+			vline.Bidirectional = true
+			break
+		default:
+			fmt.Printf("[WARNING] Field 'direction' for line (id = '%d') can't be '%s'. Setting default value = 'to_detector'\n", lsettings.LineID, lsettings.Direction)
+			vline.Direction = true
+			break
 		}
 		switch lsettings.CropMode {
 		case "crop":
@@ -72,12 +101,14 @@ func (trs *TrackerSettings) Prepare() {
 			vline.CropObject = true
 			break
 		}
+		vline.ID = lsettings.LineID
 		lsettings.VLine = vline
 	}
 	if trs.MaxPointsInTrack < 1 {
 		fmt.Printf("[WARNING] Field 'max_points_in_track' shoudle be >= 1, but got '%d'. Setting default value = 10\n", trs.MaxPointsInTrack)
 		trs.MaxPointsInTrack = 10
 	}
+	trs.SpeedEstimationSettings.Prepare()
 	if len(trs.PolygonsSettings) == 0 {
 		fmt.Println("[WARNING] No 'polygons_settings'? Please check if it is true")
 	}
@@ -88,6 +119,174 @@ func (trs *TrackerSettings) Prepare() {
 		}
 		vpolygon := NewVirtualPolygon(psettings.PolygonID, ptsCollected...)
 		vpolygon.Color = color.RGBA{psettings.RGBA[0], psettings.RGBA[1], psettings.RGBA[2], psettings.RGBA[3]}
+		switch psettings.CropMode {
+		case "crop":
+			vpolygon.CropObject = true
+			break
+		case "no_crop":
+			vpolygon.CropObject = false
+			break
+		default:
+			fmt.Printf("[WARNING] Field 'crop_mode' for polygon (id = '%d') can't be '%s'. Setting default value = 'crop'\n", psettings.PolygonID, psettings.CropMode)
+			vpolygon.CropObject = true
+			break
+		}
+		switch strings.ToLower(psettings.Anchor) {
+		case "center":
+			vpolygon.Anchor = POLYGON_ANCHOR_CENTER
+		case "bottom_center":
+			vpolygon.Anchor = POLYGON_ANCHOR_BOTTOM_CENTER
+		case "any_corner":
+			vpolygon.Anchor = POLYGON_ANCHOR_ANY_CORNER
+		case "overlap":
+			vpolygon.Anchor = POLYGON_ANCHOR_OVERLAP
+			if psettings.MinOverlap <= 0 || psettings.MinOverlap > 1 {
+				fmt.Printf("[WARNING] Field 'min_overlap' for polygon (id = '%d') should be in (0;1], but got '%f'. Setting default value = 0.5\n", psettings.PolygonID, psettings.MinOverlap)
+				psettings.MinOverlap = 0.5
+			}
+			vpolygon.MinOverlap = psettings.MinOverlap
+		case "":
+			vpolygon.Anchor = POLYGON_ANCHOR_CENTER
+		default:
+			fmt.Printf("[WARNING] Field 'anchor' for polygon (id = '%d') can't be '%s'. Setting default value = 'center'\n", psettings.PolygonID, psettings.Anchor)
+			vpolygon.Anchor = POLYGON_ANCHOR_CENTER
+		}
+		psettings.Anchor = vpolygon.Anchor.String()
+		if psettings.SpeedLimit < 0 {
+			fmt.Printf("[WARNING] Field 'speed_limit' for polygon (id = '%d') should be >= 0, but got '%f'. Setting value = 0 (no limit)\n", psettings.PolygonID, psettings.SpeedLimit)
+			psettings.SpeedLimit = 0
+		}
 		psettings.VPolygon = vpolygon
 	}
 }
+
+const (
+	defaultSpeedWindowPoints  = 10
+	defaultSpeedWindowSeconds = 1.0
+	defaultRansacThreshold    = 3.0
+	defaultSpeedMinSamples    = 3
+)
+
+// Prepare Prepares this structure for further usage
+func (ses *SpeedEstimationSettings) Prepare() {
+	ses.Estimator = strings.ToLower(ses.Estimator)
+	switch ses.Estimator {
+	case "first_last":
+		ses.estimatorType = SPEED_ESTIMATOR_FIRST_LAST
+	case "last_n_points":
+		ses.estimatorType = SPEED_ESTIMATOR_LAST_N_POINTS
+	case "last_seconds":
+		ses.estimatorType = SPEED_ESTIMATOR_LAST_SECONDS
+	case "linear_regression":
+		ses.estimatorType = SPEED_ESTIMATOR_LINEAR_REGRESSION
+	case "":
+		// Keep behaviour of previous versions by default
+		ses.estimatorType = SPEED_ESTIMATOR_FIRST_LAST
+	default:
+		fmt.Printf("[WARNING] Field 'estimator' in 'speed_estimation_settings' can't be '%s'. Setting default value = 'first_last'\n", ses.Estimator)
+		ses.estimatorType = SPEED_ESTIMATOR_FIRST_LAST
+	}
+	ses.Estimator = ses.estimatorType.String()
+	switch ses.estimatorType {
+	case SPEED_ESTIMATOR_LAST_N_POINTS, SPEED_ESTIMATOR_LINEAR_REGRESSION:
+		if ses.WindowPoints < 2 {
+			if ses.WindowPoints != 0 {
+				fmt.Printf("[WARNING] Field 'window_points' in 'speed_estimation_settings' should be >= 2, but got '%d'. Setting default value = %d\n", ses.WindowPoints, defaultSpeedWindowPoints)
+			}
+			ses.WindowPoints = defaultSpeedWindowPoints
+		}
+	case SPEED_ESTIMATOR_LAST_SECONDS:
+		if ses.WindowSeconds <= 0 {
+			if ses.WindowSeconds != 0 {
+				fmt.Printf("[WARNING] Field 'window_seconds' in 'speed_estimation_settings' should be > 0, but got '%f'. Setting default value = %f\n", ses.WindowSeconds, defaultSpeedWindowSeconds)
+			}
+			ses.WindowSeconds = defaultSpeedWindowSeconds
+		}
+	}
+	ses.MapperType = strings.ToLower(ses.MapperType)
+	switch ses.MapperType {
+	case "epsg4326":
+		ses.mapperType = MAPPER_TYPE_EPSG4326
+	case "metric":
+		ses.mapperType = MAPPER_TYPE_METRIC
+		if ses.Lane != nil && (ses.Lane.Width <= 0 || ses.Lane.Length <= 0) {
+			fmt.Printf("[WARNING] Fields 'width' and 'length' of 'lane' in 'speed_estimation_settings' should be > 0, but got '%f' and '%f'. Using 'mapper' field instead\n", ses.Lane.Width, ses.Lane.Length)
+			ses.Lane = nil
+		}
+	case "":
+		ses.mapperType = MAPPER_TYPE_EPSG4326
+	default:
+		fmt.Printf("[WARNING] Field 'mapper_type' in 'speed_estimation_settings' can't be '%s'. Setting default value = 'epsg4326'\n", ses.MapperType)
+		ses.mapperType = MAPPER_TYPE_EPSG4326
+	}
+	ses.MapperType = ses.mapperType.String()
+	if ses.mapperType != MAPPER_TYPE_METRIC && ses.Lane != nil {
+		fmt.Println("[WARNING] Field 'lane' in 'speed_estimation_settings' is used only for 'metric' mapper type. Ignoring it")
+		ses.Lane = nil
+	}
+	ses.HomographyMethod = strings.ToLower(ses.HomographyMethod)
+	switch ses.HomographyMethod {
+	case "least_squares", "":
+		ses.homographyMethod = HOMOGRAPHY_METHOD_LEAST_SQUARES
+	case "ransac":
+		ses.homographyMethod = HOMOGRAPHY_METHOD_RANSAC
+	default:
+		fmt.Printf("[WARNING] Field 'homography_method' in 'speed_estimation_settings' can't be '%s'. Setting default value = 'least_squares'\n", ses.HomographyMethod)
+		ses.homographyMethod = HOMOGRAPHY_METHOD_LEAST_SQUARES
+	}
+	ses.HomographyMethod = ses.homographyMethod.String()
+	if ses.RansacThreshold <= 0 {
+		if ses.RansacThreshold != 0 {
+			fmt.Printf("[WARNING] Field 'ransac_threshold' in 'speed_estimation_settings' should be > 0, but got '%f'. Setting default value = %f\n", ses.RansacThreshold, defaultRansacThreshold)
+		}
+		ses.RansacThreshold = defaultRansacThreshold
+	}
+	if ses.MaxReprojectionError < 0 {
+		fmt.Printf("[WARNING] Field 'max_reprojection_error' in 'speed_estimation_settings' should be >= 0, but got '%f'. Disabling check of reprojection error\n", ses.MaxReprojectionError)
+		ses.MaxReprojectionError = 0
+	}
+	ses.SpeedLimits.Prepare(ses.Enabled)
+	if ses.Smoothing < 0 || ses.Smoothing > 1 {
+		fmt.Printf("[WARNING] Field 'smoothing' in 'speed_estimation_settings' should be in [0; 1], but got '%f'. Disabling smoothing\n", ses.Smoothing)
+		ses.Smoothing = 0
+	}
+}
+
+// Prepare Prepares this structure for further usage
+//
+// speedEstimationEnabled - is speed estimation enabled? Violations can't be detected without it
+//
+func (sls *SpeedLimitsSettings) Prepare(speedEstimationEnabled bool) {
+	if !sls.Enabled {
+		return
+	}
+	if !speedEstimationEnabled {
+		fmt.Println("[WARNING] Field 'speed_limits' in 'speed_estimation_settings' is enabled, but speed estimation is disabled. Disabling speed limits...")
+		sls.Enabled = false
+		return
+	}
+	if sls.Default < 0 {
+		fmt.Printf("[WARNING] Field 'default' in 'speed_limits' should be >= 0, but got '%f'. Setting default value = 0 (no limit)\n", sls.Default)
+		sls.Default = 0
+	}
+	for className, limit := range sls.Classes {
+		if limit < 0 {
+			fmt.Printf("[WARNING] Speed limit for class '%s' in 'speed_limits' should be >= 0, but got '%f'. Setting value = 0 (no limit)\n", className, limit)
+			sls.Classes[className] = 0
+		}
+	}
+	if sls.MinSamples < 1 {
+		if sls.MinSamples != 0 {
+			fmt.Printf("[WARNING] Field 'min_samples' in 'speed_limits' should be >= 1, but got '%d'. Setting default value = %d\n", sls.MinSamples, defaultSpeedMinSamples)
+		}
+		sls.MinSamples = defaultSpeedMinSamples
+	}
+}
+
+// ClassLimit Returns speed limit for given class. Zero means no limit
+func (sls *SpeedLimitsSettings) ClassLimit(className string) float32 {
+	if limit, ok := sls.Classes[className]; ok {
+		return limit
+	}
+	return sls.Default
+}
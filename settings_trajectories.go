@@ -0,0 +1,67 @@
+package odam
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TRAJECTORY_FORMAT Alias to int
+type TRAJECTORY_FORMAT int
+
+const (
+	// TRAJECTORY_FORMAT_GEOJSON Single GeoJSON FeatureCollection. File is finished when application is closed
+	TRAJECTORY_FORMAT_GEOJSON = TRAJECTORY_FORMAT(iota + 1)
+	// TRAJECTORY_FORMAT_GEOJSONL Newline-delimited GeoJSON features (one feature per line). File stays valid even if application has crashed
+	TRAJECTORY_FORMAT_GEOJSONL
+)
+
+// String returns text representation of trajectory format (as it is used in configuration file)
+func (tf TRAJECTORY_FORMAT) String() string {
+	switch tf {
+	case TRAJECTORY_FORMAT_GEOJSON:
+		return "geojson"
+	case TRAJECTORY_FORMAT_GEOJSONL:
+		return "geojsonl"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(tf))
+	}
+}
+
+// TrajectoriesSettings Settings for export of finished tracks
+type TrajectoriesSettings struct {
+	Enabled bool `json:"enabled"`
+	// Path to output file
+	File string `json:"file"`
+	// Possible values are: geojson, geojsonl. Default is geojson
+	Format string `json:"format"`
+
+	formatType TRAJECTORY_FORMAT
+}
+
+// GetFormatType Returns enum for format option
+func (ts *TrajectoriesSettings) GetFormatType() TRAJECTORY_FORMAT {
+	return ts.formatType
+}
+
+// Prepare Prepares this structure for further usage
+func (ts *TrajectoriesSettings) Prepare() {
+	if !ts.Enabled {
+		return
+	}
+	if ts.File == "" {
+		fmt.Println("[WARNING] Field 'file' in 'trajectories_settings' has not been provided. Disabling export of trajectories...")
+		ts.Enabled = false
+		return
+	}
+	ts.Format = strings.ToLower(ts.Format)
+	switch ts.Format {
+	case "geojson", "":
+		ts.formatType = TRAJECTORY_FORMAT_GEOJSON
+	case "geojsonl":
+		ts.formatType = TRAJECTORY_FORMAT_GEOJSONL
+	default:
+		fmt.Printf("[WARNING] Field 'format' in 'trajectories_settings' can't be '%s'. Setting default value = 'geojson'\n", ts.Format)
+		ts.formatType = TRAJECTORY_FORMAT_GEOJSON
+	}
+	ts.Format = ts.formatType.String()
+}
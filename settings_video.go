@@ -2,6 +2,7 @@ package odam
 
 import (
 	"fmt"
+	"strings"
 )
 
 // VideoSettings Settings for video
@@ -12,6 +13,8 @@ type VideoSettings struct {
 	ReducedWidth  int    `json:"reduced_width"`
 	ReducedHeight int    `json:"reduced_height"`
 	CameraID      string `json:"camera_id"`
+	// Optional camera intrinsics and distortion coefficients for correction of lens distortion
+	Undistortion *UndistortionSettings `json:"undistortion"`
 
 	// Exported, but not from JSON
 	ScaleX float64 `json:"-"`
@@ -46,4 +49,53 @@ func (vs *VideoSettings) Prepare() {
 	}
 	vs.ScaleX = float64(vs.Width) / float64(vs.ReducedWidth)
 	vs.ScaleY = float64(vs.Height) / float64(vs.ReducedHeight)
+	if vs.Undistortion != nil && !vs.Undistortion.Prepare() {
+		vs.Undistortion = nil
+	}
+}
+
+// UndistortionSettings Camera intrinsics and distortion coefficients (could be obtained by camera calibration, e.g. via OpenCV's calibrateCamera())
+// Intrinsics should correspond to source resolution ('width' and 'height' fields of 'video_settings')
+type UndistortionSettings struct {
+	// Camera matrix: [[fx, 0, cx], [0, fy, cy], [0, 0, 1]]
+	CameraMatrix [3][3]float64 `json:"camera_matrix"`
+	// Distortion coefficients: k1, k2, p1, p2[, k3[, k4, k5, k6]]
+	DistortionCoefficients []float64 `json:"distortion_coefficients"`
+	// Possible values are: frame, track_points. Default is frame
+	Mode string `json:"mode"`
+
+	modeType UNDISTORTION_MODE
+}
+
+// GetModeType Returns enum for undistortion mode option
+func (us *UndistortionSettings) GetModeType() UNDISTORTION_MODE {
+	return us.modeType
+}
+
+// Prepare Prepares this structure for further usage
+// Returns false if settings are not valid and undistortion should be disabled
+func (us *UndistortionSettings) Prepare() bool {
+	if us.CameraMatrix[0][0] <= 0 || us.CameraMatrix[1][1] <= 0 || us.CameraMatrix[2][2] == 0 {
+		fmt.Println("[WARNING] Field 'camera_matrix' in 'undistortion' should contain positive focal lengths (fx, fy) and non-zero last element. Disabling undistortion...")
+		return false
+	}
+	switch len(us.DistortionCoefficients) {
+	case 4, 5, 8:
+		break
+	default:
+		fmt.Printf("[WARNING] Field 'distortion_coefficients' in 'undistortion' should contain 4, 5 or 8 elements, but got %d. Disabling undistortion...\n", len(us.DistortionCoefficients))
+		return false
+	}
+	us.Mode = strings.ToLower(us.Mode)
+	switch us.Mode {
+	case "frame", "":
+		us.modeType = UNDISTORTION_MODE_FRAME
+	case "track_points":
+		us.modeType = UNDISTORTION_MODE_TRACK_POINTS
+	default:
+		fmt.Printf("[WARNING] Field 'mode' in 'undistortion' can't be '%s'. Setting default value = 'frame'\n", us.Mode)
+		us.modeType = UNDISTORTION_MODE_FRAME
+	}
+	us.Mode = us.modeType.String()
+	return true
 }
@@ -1,6 +1,7 @@
 package odam
 
 import (
+	"fmt"
 	"image"
 	"math"
 	"time"
@@ -12,15 +13,248 @@ const (
 	earthRaidusKm = 6371 // radius of the earth in kilometers.
 )
 
+// SPEED_ESTIMATOR Alias to int
+type SPEED_ESTIMATOR int
+
+const (
+	// SPEED_ESTIMATOR_FIRST_LAST Speed is evaluated between the first and the last points of track
+	SPEED_ESTIMATOR_FIRST_LAST = SPEED_ESTIMATOR(iota + 1)
+	// SPEED_ESTIMATOR_LAST_N_POINTS Speed is evaluated between the first and the last points of last N points of track
+	SPEED_ESTIMATOR_LAST_N_POINTS
+	// SPEED_ESTIMATOR_LAST_SECONDS Speed is evaluated between the first and the last points of track registered within last T seconds
+	SPEED_ESTIMATOR_LAST_SECONDS
+	// SPEED_ESTIMATOR_LINEAR_REGRESSION Speed is evaluated as slope of linear regression of GIS-projected points over time (last N points of track are used)
+	SPEED_ESTIMATOR_LINEAR_REGRESSION
+)
+
+// String returns text representation of speed estimator (as it is used in configuration file)
+func (se SPEED_ESTIMATOR) String() string {
+	switch se {
+	case SPEED_ESTIMATOR_FIRST_LAST:
+		return "first_last"
+	case SPEED_ESTIMATOR_LAST_N_POINTS:
+		return "last_n_points"
+	case SPEED_ESTIMATOR_LAST_SECONDS:
+		return "last_seconds"
+	case SPEED_ESTIMATOR_LINEAR_REGRESSION:
+		return "linear_regression"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(se))
+	}
+}
+
+// MAPPER_TYPE Alias to int
+type MAPPER_TYPE int
+
+const (
+	// MAPPER_TYPE_EPSG4326 Image coordinates are mapped to longitude and latitude (EPSG:4326). Distance is evaluated via Haversine formula
+	MAPPER_TYPE_EPSG4326 = MAPPER_TYPE(iota + 1)
+	// MAPPER_TYPE_METRIC Image coordinates are mapped to local planar coordinates in meters. Distance is Euclidean
+	MAPPER_TYPE_METRIC
+)
+
+// String returns text representation of mapper type (as it is used in configuration file)
+func (mt MAPPER_TYPE) String() string {
+	switch mt {
+	case MAPPER_TYPE_EPSG4326:
+		return "epsg4326"
+	case MAPPER_TYPE_METRIC:
+		return "metric"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(mt))
+	}
+}
+
+// HOMOGRAPHY_METHOD Alias to int
+type HOMOGRAPHY_METHOD int
+
+const (
+	// HOMOGRAPHY_METHOD_LEAST_SQUARES All points are used for estimation of homography
+	HOMOGRAPHY_METHOD_LEAST_SQUARES = HOMOGRAPHY_METHOD(iota + 1)
+	// HOMOGRAPHY_METHOD_RANSAC Outliers are rejected by RANSAC. Useful when some of points are measured badly
+	HOMOGRAPHY_METHOD_RANSAC
+)
+
+// String returns text representation of homography method (as it is used in configuration file)
+func (hm HOMOGRAPHY_METHOD) String() string {
+	switch hm {
+	case HOMOGRAPHY_METHOD_LEAST_SQUARES:
+		return "least_squares"
+	case HOMOGRAPHY_METHOD_RANSAC:
+		return "ransac"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(hm))
+	}
+}
+
+const (
+	homographyMaxIters   = 2000
+	homographyConfidence = 0.995
+)
+
 // SpatialConverter Just wrapper for spatial conversion
 type SpatialConverter struct {
-	Function     func(gocv.Point2f) gocv.Point2f
+	Function func(gocv.Point2f) gocv.Point2f
+	// Which coordinates Function returns. Zero value is treated as MAPPER_TYPE_EPSG4326
+	Type MAPPER_TYPE
+	// Reprojection error (in pixels) for each point of mapper. Filled by NewSpatialConverter()
+	ReprojectionErrors []float64
+	// Has point of mapper been used for estimation of homography? All points are inliers for least squares method
+	Inliers      []bool
 	transformMat *gocv.Mat
+	// Converter has no calibration: Function returns points as is
+	identity bool
+}
+
+// NewIdentitySpatialConverter Creates SpatialConverter without calibration (e.g. when speed estimation is disabled)
+// Function of such converter returns points as is, so it is always safe to call it
+func NewIdentitySpatialConverter() *SpatialConverter {
+	return &SpatialConverter{
+		Function: func(pt gocv.Point2f) gocv.Point2f {
+			return pt
+		},
+		identity: true,
+	}
+}
+
+// IsIdentity Returns true if converter has no calibration, so converted points are not real world coordinates
+func (sc *SpatialConverter) IsIdentity() bool {
+	return sc == nil || sc.identity || sc.Function == nil
+}
+
+// NewSpatialConverter Creates SpatialConverter from mapper described in speed estimation settings
+// Points for 'metric' mapper are taken either from 'lane' or from 'metric' field of each 'mapper' element.
+// At least 4 points are needed. Homography is estimated from real world points to image ones (so RANSAC threshold and reprojection errors are measured in pixels) and then it is inverted.
+// If undistorter is provided then image points of mapper and every converted point are undistorted before applying homography
+func NewSpatialConverter(settings *SpeedEstimationSettings, undistorter *Undistorter) (*SpatialConverter, error) {
+	src := []gocv.Point2f{}
+	dst := []gocv.Point2f{}
+	switch settings.GetMapperType() {
+	case MAPPER_TYPE_METRIC:
+		if settings.Lane != nil {
+			lane := settings.Lane
+			// Near left corner is the origin, X axis goes across the lane, Y axis goes along the lane
+			metric := [][2]float32{{0, 0}, {lane.Width, 0}, {lane.Width, lane.Length}, {0, lane.Length}}
+			for i := range lane.ImageCoordinates {
+				src = append(src, gocv.Point2f{X: lane.ImageCoordinates[i][0], Y: lane.ImageCoordinates[i][1]})
+				dst = append(dst, gocv.Point2f{X: metric[i][0], Y: metric[i][1]})
+			}
+			break
+		}
+		for _, pair := range settings.Mapper {
+			src = append(src, gocv.Point2f{X: pair.ImageCoordinates[0], Y: pair.ImageCoordinates[1]})
+			dst = append(dst, gocv.Point2f{X: pair.Metric[0], Y: pair.Metric[1]})
+		}
+	default:
+		for _, pair := range settings.Mapper {
+			src = append(src, gocv.Point2f{X: pair.ImageCoordinates[0], Y: pair.ImageCoordinates[1]})
+			dst = append(dst, gocv.Point2f{X: pair.EPSG4326[0], Y: pair.EPSG4326[1]})
+		}
+	}
+	if len(src) < 4 {
+		return nil, fmt.Errorf("Mapper of type '%s' should contain at least 4 points, but got %d", settings.GetMapperType(), len(src))
+	}
+	if undistorter != nil {
+		for i := range src {
+			src[i] = undistorter.UndistortPoint(src[i])
+		}
+	}
+	method := gocv.HomograpyMethodAllPoints
+	if settings.GetHomographyMethod() == HOMOGRAPHY_METHOD_RANSAC {
+		method = gocv.HomograpyMethodRANSAC
+	}
+	worldMat := pointsToMat(dst)
+	defer worldMat.Close()
+	imageMat := pointsToMat(src)
+	defer imageMat.Close()
+	mask := gocv.NewMat()
+	defer mask.Close()
+	inverseMat := gocv.FindHomography(worldMat, &imageMat, method, settings.RansacThreshold, &mask, homographyMaxIters, homographyConfidence)
+	defer inverseMat.Close()
+	if inverseMat.Empty() {
+		return nil, fmt.Errorf("Can't estimate homography for mapper of type '%s'. Make sure that points are not collinear", settings.GetMapperType())
+	}
+	transformMat := gocv.NewMat()
+	gocv.Invert(inverseMat, &transformMat, gocv.SolveDecompositionLu)
+	sc := SpatialConverter{
+		Function:           perspectiveFunction(&transformMat),
+		Type:               settings.GetMapperType(),
+		ReprojectionErrors: make([]float64, len(src)),
+		Inliers:            make([]bool, len(src)),
+		transformMat:       &transformMat,
+	}
+	if undistorter != nil {
+		homography := sc.Function
+		sc.Function = func(pt gocv.Point2f) gocv.Point2f {
+			return homography(undistorter.UndistortPoint(pt))
+		}
+	}
+	project := perspectiveFunction(&inverseMat)
+	for i := range src {
+		reprojected := project(dst[i])
+		sc.ReprojectionErrors[i] = math.Hypot(float64(reprojected.X-src[i].X), float64(reprojected.Y-src[i].Y))
+		sc.Inliers[i] = mask.Empty() || mask.GetUCharAt(i, 0) != 0
+	}
+	return &sc, nil
+}
+
+// MaxReprojectionError Returns maximum reprojection error (in pixels) among inliers of mapper
+func (sc *SpatialConverter) MaxReprojectionError() float64 {
+	maxError := 0.0
+	for i, reprojectionError := range sc.ReprojectionErrors {
+		if sc.Inliers[i] && reprojectionError > maxError {
+			maxError = reprojectionError
+		}
+	}
+	return maxError
+}
+
+// pointsToMat Converts points to Nx2 matrix of type CV64F
+// Notice: matrix should be closed by caller
+func pointsToMat(points []gocv.Point2f) gocv.Mat {
+	mat := gocv.NewMatWithSize(len(points), 2, gocv.MatTypeCV64F)
+	for i, pt := range points {
+		mat.SetDoubleAt(i, 0, float64(pt.X))
+		mat.SetDoubleAt(i, 1, float64(pt.Y))
+	}
+	return mat
+}
+
+// Distance Returns distance (in kilometers) between two points which have been converted by Function
+func (sc *SpatialConverter) Distance(src, dst gocv.Point2f) float32 {
+	if sc.Type == MAPPER_TYPE_METRIC {
+		return float32(math.Hypot(float64(dst.X-src.X), float64(dst.Y-src.Y)) / 1000.0)
+	}
+	return Haversine(src, dst)
+}
+
+// planar Returns planar coordinates (in kilometers) of converted point relative to converted origin
+// For EPSG:4326 equirectangular projection is used, so it is suitable for short distances only
+func (sc *SpatialConverter) planar(origin, pt gocv.Point2f) (float64, float64) {
+	if sc.Type == MAPPER_TYPE_METRIC {
+		return float64(pt.X-origin.X) / 1000.0, float64(pt.Y-origin.Y) / 1000.0
+	}
+	cosLat := math.Cos(degreesToRadians(origin.Y))
+	x := (degreesToRadians(pt.X) - degreesToRadians(origin.X)) * cosLat * earthRaidusKm
+	y := (degreesToRadians(pt.Y) - degreesToRadians(origin.Y)) * earthRaidusKm
+	return x, y
+}
+
+// EstimateSpeed Estimates speed (km/h) between two points (in image coordinates)
+func (sc *SpatialConverter) EstimateSpeed(firstPoint, lastPoint gocv.Point2f, start, end time.Time) float32 {
+	fpreal := sc.Function(firstPoint)
+	lpreal := sc.Function(lastPoint)
+	return sc.Distance(fpreal, lpreal) / float32(end.Sub(start).Hours())
 }
 
 // Close Free memory for underlying *gocv.Mat
+// It is safe to call it for identity converter and multiple times
 func (sc *SpatialConverter) Close() {
+	if sc == nil || sc.transformMat == nil {
+		return
+	}
 	sc.transformMat.Close()
+	sc.transformMat = nil
 }
 
 // GetPerspectiveTransformer Initializates gocv.Point2f for GIS conversion purposes
@@ -28,7 +262,12 @@ func GetPerspectiveTransformer(srcPoints, dstPoints []gocv.Point2f) (*gocv.Mat,
 	src := gocv.NewPoint2fVectorFromPoints(srcPoints)
 	trgt := gocv.NewPoint2fVectorFromPoints(dstPoints)
 	transformMat := gocv.GetPerspectiveTransform2f(src, trgt)
-	return &transformMat, func(src gocv.Point2f) gocv.Point2f {
+	return &transformMat, perspectiveFunction(&transformMat)
+}
+
+// perspectiveFunction Returns function which applies given 3x3 perspective transformation to a point
+func perspectiveFunction(transformMat *gocv.Mat) func(gocv.Point2f) gocv.Point2f {
+	return func(src gocv.Point2f) gocv.Point2f {
 		pmat := gocv.NewMatWithSize(3, 1, gocv.MatTypeCV64F)
 		pmat.SetDoubleAt(0, 0, float64(src.X))
 		pmat.SetDoubleAt(1, 0, float64(src.Y))
@@ -50,6 +289,81 @@ func EstimateSpeed(firstPoint, lastPoint gocv.Point2f, start, end time.Time, per
 	return Haversine(fpreal, lpreal) / float32(end.Sub(start).Hours())
 }
 
+// EstimateTrackSpeed Estimates speed of object by its track using configured estimator (see ref. SPEED_ESTIMATOR)
+// Returns false if there is not enough data for estimation (e.g. less than two points or zero time difference)
+//
+// track - points of track [scaled]
+// timestamps - timestamps of track's points (should have the same length as track)
+// settings - speed estimation settings (should be prepared)
+// converter - conversion of image coordinates to real world ones
+//
+func EstimateTrackSpeed(track []image.Point, timestamps []time.Time, settings *SpeedEstimationSettings, converter *SpatialConverter) (float32, bool) {
+	n := len(track)
+	if len(timestamps) < n {
+		n = len(timestamps)
+	}
+	if n < 2 || converter.IsIdentity() {
+		return 0, false
+	}
+	from := 0
+	switch settings.estimatorType {
+	case SPEED_ESTIMATOR_LAST_N_POINTS, SPEED_ESTIMATOR_LINEAR_REGRESSION:
+		from = maxInt(0, n-settings.WindowPoints)
+	case SPEED_ESTIMATOR_LAST_SECONDS:
+		windowStart := timestamps[n-1].Add(-time.Duration(settings.WindowSeconds * float64(time.Second)))
+		from = n - 2
+		for from > 0 && !timestamps[from-1].Before(windowStart) {
+			from--
+		}
+	default:
+		break
+	}
+	if settings.estimatorType == SPEED_ESTIMATOR_LINEAR_REGRESSION {
+		return regressionSpeed(track[from:n], timestamps[from:n], converter)
+	}
+	if !timestamps[n-1].After(timestamps[from]) {
+		return 0, false
+	}
+	return converter.EstimateSpeed(STDPointToGoCVPoint2F(track[from]), STDPointToGoCVPoint2F(track[n-1]), timestamps[from], timestamps[n-1]), true
+}
+
+// regressionSpeed Estimates speed (km/h) as slope of linear regression of converted points over time
+// Points are projected to local plane (see ref. SpatialConverter.planar())
+func regressionSpeed(track []image.Point, timestamps []time.Time, converter *SpatialConverter) (float32, bool) {
+	n := float64(len(track))
+	origin := converter.Function(STDPointToGoCVPoint2F(track[0]))
+	sumT, sumX, sumY, sumTT, sumTX, sumTY := 0.0, 0.0, 0.0, 0.0, 0.0, 0.0
+	for i := range track {
+		// Kilometers from origin
+		x, y := converter.planar(origin, converter.Function(STDPointToGoCVPoint2F(track[i])))
+		// Hours from the first point
+		t := timestamps[i].Sub(timestamps[0]).Hours()
+		sumT += t
+		sumX += x
+		sumY += y
+		sumTT += t * t
+		sumTX += t * x
+		sumTY += t * y
+	}
+	denominator := n*sumTT - sumT*sumT
+	if denominator <= 0 {
+		return 0, false
+	}
+	vx := (n*sumTX - sumT*sumX) / denominator
+	vy := (n*sumTY - sumT*sumY) / denominator
+	return float32(math.Hypot(vx, vy)), true
+}
+
+// SmoothSpeed Applies exponential smoothing to speed estimation
+//
+// previous - previous (smoothed) value of speed
+// current - new estimation of speed
+// alpha - smoothing factor in (0; 1]. The bigger value is, the more weight the new estimation has. Value 1 disables smoothing
+//
+func SmoothSpeed(previous, current float32, alpha float64) float32 {
+	return float32(alpha*float64(current) + (1-alpha)*float64(previous))
+}
+
 // Haversine Calculates great circle distance between two points
 // https://en.wikipedia.org/wiki/Great-circle_distance#:~:text=The%20great%2Dcircle%20distance%2C%20orthodromic,line%20through%20the%20sphere's%20interior).
 func Haversine(src, dst gocv.Point2f) float32 {
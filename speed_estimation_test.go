@@ -1,9 +1,12 @@
 package odam
 
 import (
+	"image"
+	"math"
 	"testing"
 	"time"
 
+	blob "github.com/LdDl/gocv-blob/v2/blob"
 	"gocv.io/x/gocv"
 )
 
@@ -65,3 +68,236 @@ func TestEstimateSpeed(t *testing.T) {
 		t.Errorf("Estimated speed should be %f, but got %f", res, correctSpeed)
 	}
 }
+
+func TestEstimateTrackSpeed(t *testing.T) {
+	// Simple linear mapping: 1 pixel is 1e-5 degree of longitude near equator
+	converter := &SpatialConverter{
+		Function: func(p gocv.Point2f) gocv.Point2f {
+			return gocv.Point2f{X: p.X * 1e-5, Y: p.Y * 1e-5}
+		},
+	}
+	start := time.Unix(0, 0)
+	// Object moves with 10 pixels per second for 5 seconds and then with 30 pixels per second for 2 seconds
+	track := []image.Point{}
+	timestamps := []time.Time{}
+	for i := 0; i <= 5; i++ {
+		track = append(track, image.Point{X: 10 * i, Y: 0})
+		timestamps = append(timestamps, start.Add(time.Duration(i)*time.Second))
+	}
+	for i := 1; i <= 2; i++ {
+		track = append(track, image.Point{X: 50 + 30*i, Y: 0})
+		timestamps = append(timestamps, start.Add(time.Duration(5+i)*time.Second))
+	}
+	pixelKm := float64(Haversine(gocv.Point2f{X: 0, Y: 0}, gocv.Point2f{X: 1e-5, Y: 0}))
+	slowSpeed := 10 * pixelKm * 3600
+	fastSpeed := 30 * pixelKm * 3600
+
+	settings := SpeedEstimationSettings{}
+	settings.Prepare()
+	averageSpeed, ok := EstimateTrackSpeed(track, timestamps, &settings, converter)
+	if !ok || math.Abs(float64(averageSpeed)-110.0/7.0*pixelKm*3600) > 0.01*slowSpeed {
+		t.Errorf("Speed for whole track should be %f, but got %f (%t)", 110.0/7.0*pixelKm*3600, averageSpeed, ok)
+	}
+
+	settings = SpeedEstimationSettings{Estimator: "last_n_points", WindowPoints: 3}
+	settings.Prepare()
+	lastPointsSpeed, ok := EstimateTrackSpeed(track, timestamps, &settings, converter)
+	if !ok || math.Abs(float64(lastPointsSpeed)-fastSpeed) > 0.01*fastSpeed {
+		t.Errorf("Speed for last 3 points should be %f, but got %f (%t)", fastSpeed, lastPointsSpeed, ok)
+	}
+
+	settings = SpeedEstimationSettings{Estimator: "last_seconds", WindowSeconds: 2}
+	settings.Prepare()
+	lastSecondsSpeed, ok := EstimateTrackSpeed(track, timestamps, &settings, converter)
+	if !ok || math.Abs(float64(lastSecondsSpeed)-fastSpeed) > 0.01*fastSpeed {
+		t.Errorf("Speed for last 2 seconds should be %f, but got %f (%t)", fastSpeed, lastSecondsSpeed, ok)
+	}
+
+	settings = SpeedEstimationSettings{Estimator: "linear_regression", WindowPoints: 6}
+	settings.Prepare()
+	regressionSpeed, ok := EstimateTrackSpeed(track[:6], timestamps[:6], &settings, converter)
+	if !ok || math.Abs(float64(regressionSpeed)-slowSpeed) > 0.01*slowSpeed {
+		t.Errorf("Regression speed for uniform motion should be %f, but got %f (%t)", slowSpeed, regressionSpeed, ok)
+	}
+
+	if _, ok := EstimateTrackSpeed(track[:1], timestamps[:1], &settings, converter); ok {
+		t.Errorf("Speed should not be estimated for single point")
+	}
+	if _, ok := EstimateTrackSpeed([]image.Point{{X: 0, Y: 0}, {X: 10, Y: 0}}, []time.Time{start, start}, &settings, converter); ok {
+		t.Errorf("Speed should not be estimated for zero time difference")
+	}
+}
+
+func TestSmoothSpeed(t *testing.T) {
+	spd := SmoothSpeed(40, 60, 0.25)
+	if math.Abs(float64(spd)-45) > 1e-5 {
+		t.Errorf("Smoothed speed should be 45, but got %f", spd)
+	}
+}
+
+func TestMetricSpeed(t *testing.T) {
+	// 1 pixel is 0.1 meter
+	converter := &SpatialConverter{
+		Type: MAPPER_TYPE_METRIC,
+		Function: func(p gocv.Point2f) gocv.Point2f {
+			return gocv.Point2f{X: p.X * 0.1, Y: p.Y * 0.1}
+		},
+	}
+	dist := converter.Distance(gocv.Point2f{X: 0, Y: 0}, gocv.Point2f{X: 30, Y: 40})
+	if math.Abs(float64(dist)-0.05) > 1e-7 {
+		t.Errorf("Distance should be 0.05 km, but got %f", dist)
+	}
+	// 100 pixels per second is 10 m/s which is 36 km/h
+	start := time.Unix(0, 0)
+	track := []image.Point{{X: 0, Y: 0}, {X: 60, Y: 80}, {X: 120, Y: 160}}
+	timestamps := []time.Time{start, start.Add(time.Second), start.Add(2 * time.Second)}
+	for _, estimator := range []string{"first_last", "linear_regression"} {
+		settings := SpeedEstimationSettings{Estimator: estimator, MapperType: "metric"}
+		settings.Prepare()
+		spd, ok := EstimateTrackSpeed(track, timestamps, &settings, converter)
+		if !ok || math.Abs(float64(spd)-36) > 1e-3 {
+			t.Errorf("Speed estimated by '%s' should be 36 km/h, but got %f (%t)", estimator, spd, ok)
+		}
+	}
+}
+
+func TestNewSpatialConverterLane(t *testing.T) {
+	settings := SpeedEstimationSettings{
+		MapperType: "metric",
+		Lane: &LaneMapper{
+			ImageCoordinates: [4][2]float32{{100, 700}, {300, 700}, {260, 300}, {140, 300}},
+			Width:            3.5,
+			Length:           30,
+		},
+	}
+	settings.Prepare()
+	converter, err := NewSpatialConverter(&settings, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer converter.Close()
+	far := converter.Function(gocv.Point2f{X: 140, Y: 300})
+	if math.Abs(float64(far.X)) > 1e-3 || math.Abs(float64(far.Y)-30) > 1e-3 {
+		t.Errorf("Far left corner should be mapped to (0, 30), but got %v", far)
+	}
+	if dist := converter.Distance(converter.Function(gocv.Point2f{X: 100, Y: 700}), far); math.Abs(float64(dist)-0.03) > 1e-5 {
+		t.Errorf("Length of lane should be 0.03 km, but got %f", dist)
+	}
+}
+
+func TestNewSpatialConverterRANSAC(t *testing.T) {
+	// Image points are produced by known planar mapping: 1 meter is 10 pixels, origin is at (100, 100)
+	mapper := []GISMapper{}
+	for _, metric := range [][2]float32{{0, 0}, {10, 0}, {10, 20}, {0, 20}, {5, 10}, {3, 15}} {
+		mapper = append(mapper, GISMapper{
+			ImageCoordinates: [2]float32{100 + metric[0]*10, 100 + metric[1]*10},
+			Metric:           metric,
+		})
+	}
+	// Badly measured point
+	mapper[4].ImageCoordinates[0] += 40
+	settings := SpeedEstimationSettings{MapperType: "metric", Mapper: mapper, HomographyMethod: "ransac", RansacThreshold: 2}
+	settings.Prepare()
+	converter, err := NewSpatialConverter(&settings, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer converter.Close()
+	for i := range mapper {
+		if converter.Inliers[i] != (i != 4) {
+			t.Errorf("Point #%d should be inlier = %t (reprojection error is %f px)", i, i != 4, converter.ReprojectionErrors[i])
+		}
+	}
+	if converter.MaxReprojectionError() > 0.01 {
+		t.Errorf("Reprojection error for inliers should be close to zero, but got %f px", converter.MaxReprojectionError())
+	}
+	if converter.ReprojectionErrors[4] < 39 {
+		t.Errorf("Reprojection error for outlier should be about 40 px, but got %f px", converter.ReprojectionErrors[4])
+	}
+}
+
+func TestCheckReprojectionErrors(t *testing.T) {
+	converter := &SpatialConverter{
+		ReprojectionErrors: []float64{0.5, 2.5, 10},
+		Inliers:            []bool{true, true, false},
+	}
+	settings := SpeedEstimationSettings{MaxReprojectionError: 3, RefuseOnReprojectionError: true}
+	settings.Prepare()
+	if err := checkReprojectionErrors(converter, &settings); err != nil {
+		t.Errorf("Outliers should not be taken into account: %s", err.Error())
+	}
+	settings.MaxReprojectionError = 2
+	if err := checkReprojectionErrors(converter, &settings); err == nil {
+		t.Errorf("Application should refuse to start when reprojection error exceeds tolerance")
+	}
+	settings.RefuseOnReprojectionError = false
+	if err := checkReprojectionErrors(converter, &settings); err != nil {
+		t.Errorf("Only warning should be printed when refusing is disabled: %s", err.Error())
+	}
+}
+
+func TestIdentitySpatialConverter(t *testing.T) {
+	converter := NewIdentitySpatialConverter()
+	if !converter.IsIdentity() {
+		t.Errorf("Converter should be identity one")
+	}
+	pt := gocv.Point2f{X: 12, Y: 34}
+	if converted := converter.Function(pt); converted != pt {
+		t.Errorf("Identity converter should return point as is, but got %v", converted)
+	}
+	timestamps := []time.Time{time.Unix(0, 0), time.Unix(1, 0)}
+	if _, ok := EstimateTrackSpeed([]image.Point{{0, 0}, {10, 10}}, timestamps, &SpeedEstimationSettings{}, converter); ok {
+		t.Errorf("Speed can't be estimated without calibration")
+	}
+	// Closing of converter without matrix should not panic
+	converter.Close()
+	converter.Close()
+	(&SpatialConverter{}).Close()
+	var nilConverter *SpatialConverter
+	nilConverter.Close()
+	if !nilConverter.IsIdentity() {
+		t.Errorf("Nil converter should be treated as identity one")
+	}
+
+	b := blob.NewSimpleBlobie(image.Rect(0, 0, 10, 20), nil)
+	track := TrackInfoInfoGRPC(b, "speed", 2, 2, converter)
+	for i, point := range track.Points {
+		if point.EuclideanPoint == nil {
+			t.Errorf("Point #%d should have Euclidean coordinates", i)
+		}
+		if point.Wgs84Point != nil || point.MetricPoint != nil {
+			t.Errorf("Point #%d should not have real world coordinates without calibration", i)
+		}
+	}
+	if track = TrackInfoInfoGRPC(b, "speed", 2, 2, nil); len(track.Points) == 0 {
+		t.Errorf("Track info should be prepared even without converter")
+	}
+}
+
+func TestBlobTrackUpdated(t *testing.T) {
+	start := time.Unix(0, 0)
+	allblobies := blob.NewBlobiesDefaults()
+	rect := image.Rect(0, 0, 10, 10)
+	allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(rect, &blob.BlobOptions{Time: start, TimeDeltaSeconds: 1})})
+	var b blob.Blobie
+	for _, tracked := range allblobies.Objects {
+		b = tracked
+	}
+	if !blobTrackUpdated(b) {
+		t.Errorf("New object should be treated as updated")
+	}
+	if blobTrackUpdated(b) {
+		t.Errorf("Track has not gained new point, so object should not be treated as updated")
+	}
+	// Object has not been matched on next frame: its track is unchanged
+	allblobies.MatchToExisting([]blob.Blobie{})
+	if blobTrackUpdated(b) {
+		t.Errorf("Object has not been matched, so it should not be treated as updated")
+	}
+	allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(rect.Add(image.Point{X: 2}), &blob.BlobOptions{Time: start.Add(2 * time.Second), TimeDeltaSeconds: 1})})
+	if !blobTrackUpdated(b) {
+		t.Errorf("Object has been matched, so it should be treated as updated")
+	}
+}
@@ -0,0 +1,68 @@
+package odam
+
+import (
+	"time"
+
+	blob "github.com/LdDl/gocv-blob/v2/blob"
+)
+
+// SpeedRecord Single estimation of speed of object
+type SpeedRecord struct {
+	Timestamp time.Time
+	Speed     float32
+}
+
+const (
+	blobSpeedRecordsProperty   = "speed_records"
+	blobSpeedViolationProperty = "speed_violation"
+)
+
+// appendSpeedRecord Remembers estimation of speed in blob's properties. Only last maxRecords estimations are kept
+// Timestamp of record should be timestamp of the last point of track: estimation is skipped if track has not gained new point since previous record,
+// so repeated estimations for missed detections are not counted as consecutive samples
+// Returns false if record has been skipped
+func appendSpeedRecord(b blob.Blobie, record SpeedRecord, maxRecords int) bool {
+	records := blobSpeedRecords(b)
+	if n := len(records); n != 0 && !record.Timestamp.After(records[n-1].Timestamp) {
+		return false
+	}
+	records = append(records, record)
+	if len(records) > maxRecords {
+		records = records[len(records)-maxRecords:]
+	}
+	b.SetProperty(blobSpeedRecordsProperty, records)
+	return true
+}
+
+// blobSpeedRecords Returns last estimations of speed of object
+func blobSpeedRecords(b blob.Blobie) []SpeedRecord {
+	if prop, ok := b.GetProperty(blobSpeedRecordsProperty); ok {
+		if records, ok := prop.([]SpeedRecord); ok {
+			return records
+		}
+	}
+	return nil
+}
+
+// isBlobViolatedSpeed Checks if violation of speed limit has been registered for object already
+func isBlobViolatedSpeed(b blob.Blobie) bool {
+	prop, ok := b.GetProperty(blobSpeedViolationProperty)
+	if !ok {
+		return false
+	}
+	violated, ok := prop.(bool)
+	return ok && violated
+}
+
+// speedLimitExceeded Checks if each of last minSamples estimations of speed exceeds limit
+func speedLimitExceeded(records []SpeedRecord, limit float32, minSamples int) bool {
+	if limit <= 0 || len(records) < minSamples {
+		return false
+	}
+	for _, record := range records[len(records)-minSamples:] {
+		if record.Speed <= limit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package odam
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	blob "github.com/LdDl/gocv-blob/v2/blob"
+)
+
+func TestSpeedLimitExceeded(t *testing.T) {
+	start := time.Unix(0, 0)
+	records := []SpeedRecord{
+		{Timestamp: start, Speed: 70},
+		{Timestamp: start.Add(time.Second), Speed: 55},
+		{Timestamp: start.Add(2 * time.Second), Speed: 65},
+		{Timestamp: start.Add(3 * time.Second), Speed: 66},
+	}
+	if !speedLimitExceeded(records, 60, 2) {
+		t.Errorf("Last 2 estimations exceed limit 60, so violation should be registered")
+	}
+	if speedLimitExceeded(records, 60, 3) {
+		t.Errorf("Not every of last 3 estimations exceeds limit 60, so violation should not be registered")
+	}
+	if speedLimitExceeded(records, 0, 1) {
+		t.Errorf("Zero limit means no limit")
+	}
+	if speedLimitExceeded(records[:1], 60, 2) {
+		t.Errorf("There are not enough estimations for violation")
+	}
+}
+
+func TestSpeedLimitsSettings(t *testing.T) {
+	sls := SpeedLimitsSettings{
+		Enabled: true,
+		Default: 90,
+		Classes: map[string]float32{"truck": 70, "bus": -1},
+	}
+	sls.Prepare(true)
+	if sls.MinSamples != defaultSpeedMinSamples {
+		t.Errorf("Default number of samples should be %d, but got %d", defaultSpeedMinSamples, sls.MinSamples)
+	}
+	limits := map[string]float32{"truck": 70, "bus": 0, "car": 90}
+	for className, limit := range limits {
+		if sls.ClassLimit(className) != limit {
+			t.Errorf("Limit for class '%s' should be %f, but got %f", className, limit, sls.ClassLimit(className))
+		}
+	}
+	disabled := SpeedLimitsSettings{Enabled: true}
+	disabled.Prepare(false)
+	if disabled.Enabled {
+		t.Errorf("Speed limits should be disabled when speed estimation is disabled")
+	}
+}
+
+func TestSpeedRecords(t *testing.T) {
+	b := blob.NewSimpleBlobie(image.Rect(0, 0, 10, 10), nil)
+	start := time.Unix(0, 0)
+	for i := 0; i < 5; i++ {
+		appendSpeedRecord(b, SpeedRecord{Timestamp: start.Add(time.Duration(i) * time.Second), Speed: float32(50 + i)}, 3)
+	}
+	records := blobSpeedRecords(b)
+	if len(records) != 3 || records[0].Speed != 52 || records[2].Speed != 54 {
+		t.Errorf("Only last 3 estimations should be kept, but got %v", records)
+	}
+	if isBlobViolatedSpeed(b) {
+		t.Errorf("Violation has not been registered yet")
+	}
+}
+
+func TestSpeedRecordsMissedDetections(t *testing.T) {
+	b := blob.NewSimpleBlobie(image.Rect(0, 0, 10, 10), nil)
+	start := time.Unix(0, 0)
+	minSamples := 3
+	if !appendSpeedRecord(b, SpeedRecord{Timestamp: start, Speed: 50}, minSamples) {
+		t.Errorf("First estimation should be recorded")
+	}
+	// Single bad estimation followed by missed detections: track is not changed, so the same estimation is repeated with the same track timestamp
+	for i := 0; i < minSamples; i++ {
+		appendSpeedRecord(b, SpeedRecord{Timestamp: start.Add(time.Second), Speed: 120}, minSamples)
+	}
+	records := blobSpeedRecords(b)
+	if len(records) != 2 {
+		t.Errorf("Repeated estimations for unchanged track should be skipped: 2 records are expected, but got %d", len(records))
+	}
+	if speedLimitExceeded(records, 60, minSamples) {
+		t.Errorf("Single estimation above limit should not trigger violation")
+	}
+	appendSpeedRecord(b, SpeedRecord{Timestamp: start.Add(2 * time.Second), Speed: 110}, minSamples)
+	appendSpeedRecord(b, SpeedRecord{Timestamp: start.Add(3 * time.Second), Speed: 115}, minSamples)
+	if !speedLimitExceeded(blobSpeedRecords(b), 60, minSamples) {
+		t.Errorf("%d consecutive estimations above limit should trigger violation", minSamples)
+	}
+}
@@ -0,0 +1,255 @@
+package odam
+
+import (
+	"bufio"
+	"encoding/json"
+	"image"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	blob "github.com/LdDl/gocv-blob/v2/blob"
+	"github.com/pkg/errors"
+)
+
+// trajectory Full track of object. Unlike blob's track it is not truncated by 'max_points_in_track'
+type trajectory struct {
+	points       []image.Point
+	timestamps   []time.Time
+	speedSum     float64
+	speedSamples int
+	maxSpeed     float32
+	// Timestamp of track's point for the last registered estimation of speed
+	speedAt  time.Time
+	polygons map[int64]struct{}
+}
+
+const (
+	blobTrajectoryProperty = "trajectory"
+)
+
+// blobTrajectory Returns full track of object
+// Trajectory is stored in blob's properties, so it is created on first call
+func blobTrajectory(b blob.Blobie) *trajectory {
+	if prop, ok := b.GetProperty(blobTrajectoryProperty); ok {
+		if tr, ok := prop.(*trajectory); ok {
+			return tr
+		}
+	}
+	tr := trajectory{
+		polygons: make(map[int64]struct{}),
+	}
+	b.SetProperty(blobTrajectoryProperty, &tr)
+	return &tr
+}
+
+// addPoint Appends point of track [scaled]. Point is skipped if there is already point with the same timestamp
+func (tr *trajectory) addPoint(pt image.Point, t time.Time) {
+	if n := len(tr.timestamps); n != 0 && !t.After(tr.timestamps[n-1]) {
+		return
+	}
+	tr.points = append(tr.points, pt)
+	tr.timestamps = append(tr.timestamps, t)
+}
+
+// addSpeed Registers estimation of speed for track which ends with point at given timestamp
+// Estimation is skipped if track has not gained new point since previous one (e.g. object has not been detected), so mean speed is not weighted towards missed detections
+func (tr *trajectory) addSpeed(spd float32, t time.Time) {
+	if tr.speedSamples != 0 && !t.After(tr.speedAt) {
+		return
+	}
+	tr.speedAt = t
+	tr.speedSum += float64(spd)
+	tr.speedSamples++
+	if spd > tr.maxSpeed {
+		tr.maxSpeed = spd
+	}
+}
+
+// visitPolygon Registers polygon which object has entered
+func (tr *trajectory) visitPolygon(polygonID int64) {
+	tr.polygons[polygonID] = struct{}{}
+}
+
+// GeoJSONLineString Geometry of GeoJSON feature. See ref. https://datatracker.ietf.org/doc/html/rfc7946#section-3.1.4
+type GeoJSONLineString struct {
+	Type        string       `json:"type"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+// TrajectoryProperties Properties of GeoJSON feature which represents finished track
+type TrajectoryProperties struct {
+	ObjectID  string `json:"object_id"`
+	ClassName string `json:"class_name"`
+	CamID     string `json:"cam_id"`
+	// First and last timestamps of track (RFC3339, UTC)
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+	// Timestamp of each vertex in Unix UTC (milliseconds)
+	Timestamps []int64 `json:"timestamps"`
+	// Speed properties are empty when speed has not been estimated
+	MeanSpeed *float64 `json:"mean_speed,omitempty"`
+	MaxSpeed  *float64 `json:"max_speed,omitempty"`
+	// Identifiers of crossed virtual lines
+	Lines []int64 `json:"lines"`
+	// Identifiers of visited virtual polygons
+	Polygons []int64 `json:"polygons"`
+}
+
+// GeoJSONFeature Finished track as GeoJSON feature. See ref. https://datatracker.ietf.org/doc/html/rfc7946#section-3.2
+type GeoJSONFeature struct {
+	Type       string               `json:"type"`
+	Geometry   GeoJSONLineString    `json:"geometry"`
+	Properties TrajectoryProperties `json:"properties"`
+}
+
+// TrajectoryFeature Prepares GeoJSON feature for finished track of object
+// GeoJSON requires WGS84 coordinates (see ref. https://datatracker.ietf.org/doc/html/rfc7946#section-4), so converter should be of 'epsg4326' type
+// Returns false if track has less than two points (it can't be represented as LineString) or if converter can't provide WGS84 coordinates
+//
+// b - object
+// camID - identifier of video source
+// converter - conversion of image coordinates to [Longitude; Latitude] pairs
+//
+func TrajectoryFeature(b blob.Blobie, camID string, converter *SpatialConverter) (*GeoJSONFeature, bool) {
+	if !trajectoryConverterSupported(converter) {
+		return nil, false
+	}
+	tr := blobTrajectory(b)
+	if len(tr.points) < 2 {
+		return nil, false
+	}
+	feature := GeoJSONFeature{
+		Type: "Feature",
+		Geometry: GeoJSONLineString{
+			Type:        "LineString",
+			Coordinates: make([][2]float64, len(tr.points)),
+		},
+		Properties: TrajectoryProperties{
+			ObjectID:   b.GetID().String(),
+			ClassName:  b.GetClassName(),
+			CamID:      camID,
+			StartedAt:  tr.timestamps[0].UTC().Format(time.RFC3339Nano),
+			FinishedAt: tr.timestamps[len(tr.timestamps)-1].UTC().Format(time.RFC3339Nano),
+			Timestamps: make([]int64, len(tr.timestamps)),
+			Lines:      BlobCrossedLinesIDs(b),
+			Polygons:   make([]int64, 0, len(tr.polygons)),
+		},
+	}
+	for i, pt := range tr.points {
+		converted := converter.Function(STDPointToGoCVPoint2F(pt))
+		feature.Geometry.Coordinates[i] = [2]float64{float64(converted.X), float64(converted.Y)}
+	}
+	for i, t := range tr.timestamps {
+		feature.Properties.Timestamps[i] = t.UTC().UnixNano() / int64(time.Millisecond)
+	}
+	if tr.speedSamples > 0 {
+		meanSpeed := tr.speedSum / float64(tr.speedSamples)
+		maxSpeed := float64(tr.maxSpeed)
+		feature.Properties.MeanSpeed = &meanSpeed
+		feature.Properties.MaxSpeed = &maxSpeed
+	}
+	for id := range tr.polygons {
+		feature.Properties.Polygons = append(feature.Properties.Polygons, id)
+	}
+	sort.Slice(feature.Properties.Polygons, func(i, j int) bool {
+		return feature.Properties.Polygons[i] < feature.Properties.Polygons[j]
+	})
+	return &feature, true
+}
+
+// trajectoryConverterSupported Checks if converter provides WGS84 coordinates, which are required by GeoJSON
+// Zero value of converter's type is treated as MAPPER_TYPE_EPSG4326
+func trajectoryConverterSupported(converter *SpatialConverter) bool {
+	return !converter.IsIdentity() && converter.Type != MAPPER_TYPE_METRIC
+}
+
+// TrajectoryWriter Writes finished tracks to file as GeoJSON features
+type TrajectoryWriter struct {
+	sync.Mutex
+	file        *os.File
+	writer      *bufio.Writer
+	format      TRAJECTORY_FORMAT
+	featuresNum int
+	closed      bool
+}
+
+// NewTrajectoryWriter Constructor for TrajectoryWriter. File is created (or truncated)
+//
+// fname - path to output file
+// format - GeoJSON FeatureCollection or newline-delimited GeoJSON features
+//
+func NewTrajectoryWriter(fname string, format TRAJECTORY_FORMAT) (*TrajectoryWriter, error) {
+	file, err := os.Create(fname)
+	if err != nil {
+		return nil, errors.Wrap(err, "Can't create file for trajectories")
+	}
+	tw := TrajectoryWriter{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		format: format,
+	}
+	if format != TRAJECTORY_FORMAT_GEOJSONL {
+		_, err = tw.writer.WriteString(`{"type":"FeatureCollection","features":[` + "\n")
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrap(err, "Can't write header of FeatureCollection")
+		}
+	}
+	return &tw, nil
+}
+
+// Write Writes single feature
+func (tw *TrajectoryWriter) Write(feature *GeoJSONFeature) error {
+	tw.Lock()
+	defer tw.Unlock()
+	if tw.closed {
+		return errors.New("Writer of trajectories has been closed")
+	}
+	bytes, err := json.Marshal(feature)
+	if err != nil {
+		return errors.Wrap(err, "Can't marshal trajectory")
+	}
+	if tw.format != TRAJECTORY_FORMAT_GEOJSONL && tw.featuresNum > 0 {
+		if _, err = tw.writer.WriteString(",\n"); err != nil {
+			return errors.Wrap(err, "Can't write features separator")
+		}
+	}
+	if _, err = tw.writer.Write(bytes); err != nil {
+		return errors.Wrap(err, "Can't write trajectory")
+	}
+	if tw.format == TRAJECTORY_FORMAT_GEOJSONL {
+		if _, err = tw.writer.WriteString("\n"); err != nil {
+			return errors.Wrap(err, "Can't write features separator")
+		}
+		// Keep file valid as much as possible
+		if err = tw.writer.Flush(); err != nil {
+			return errors.Wrap(err, "Can't flush trajectory")
+		}
+	}
+	tw.featuresNum++
+	return nil
+}
+
+// Close Finishes FeatureCollection (if needed) and closes file
+// It is safe to call it multiple times
+func (tw *TrajectoryWriter) Close() error {
+	tw.Lock()
+	defer tw.Unlock()
+	if tw.closed {
+		return nil
+	}
+	tw.closed = true
+	if tw.format != TRAJECTORY_FORMAT_GEOJSONL {
+		if _, err := tw.writer.WriteString("\n]}\n"); err != nil {
+			tw.file.Close()
+			return errors.Wrap(err, "Can't write footer of FeatureCollection")
+		}
+	}
+	if err := tw.writer.Flush(); err != nil {
+		tw.file.Close()
+		return errors.Wrap(err, "Can't flush trajectories")
+	}
+	return tw.file.Close()
+}
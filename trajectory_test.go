@@ -0,0 +1,175 @@
+package odam
+
+import (
+	"bufio"
+	"encoding/json"
+	"image"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	blob "github.com/LdDl/gocv-blob/v2/blob"
+	"gocv.io/x/gocv"
+)
+
+func TestTrajectoriesSettings(t *testing.T) {
+	ts := TrajectoriesSettings{Enabled: true, File: "tracks.geojson", Format: "GeoJSONL"}
+	ts.Prepare()
+	if ts.GetFormatType() != TRAJECTORY_FORMAT_GEOJSONL {
+		t.Errorf("Format should be '%s', but got '%s'", TRAJECTORY_FORMAT_GEOJSONL, ts.GetFormatType())
+	}
+	ts = TrajectoriesSettings{Enabled: true, File: "tracks.geojson", Format: "shp"}
+	ts.Prepare()
+	if ts.GetFormatType() != TRAJECTORY_FORMAT_GEOJSON {
+		t.Errorf("Unknown format should fall back to '%s', but got '%s'", TRAJECTORY_FORMAT_GEOJSON, ts.GetFormatType())
+	}
+	ts = TrajectoriesSettings{Enabled: true}
+	ts.Prepare()
+	if ts.Enabled {
+		t.Errorf("Export should be disabled when file is not provided")
+	}
+}
+
+func TestTrajectoryWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "odam_trajectories")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	feature := GeoJSONFeature{
+		Type: "Feature",
+		Geometry: GeoJSONLineString{
+			Type:        "LineString",
+			Coordinates: [][2]float64{{37.61, 55.75}, {37.62, 55.76}},
+		},
+		Properties: TrajectoryProperties{ObjectID: "1", ClassName: "car"},
+	}
+
+	fname := filepath.Join(dir, "tracks.geojson")
+	writer, err := NewTrajectoryWriter(fname, TRAJECTORY_FORMAT_GEOJSON)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 2; i++ {
+		if err = writer.Write(&feature); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if err = writer.Close(); err != nil {
+		t.Error(err)
+		return
+	}
+	if err = writer.Close(); err != nil {
+		t.Errorf("Second call of Close() should do nothing, but got error: %s", err.Error())
+	}
+	contents, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	collection := struct {
+		Type     string           `json:"type"`
+		Features []GeoJSONFeature `json:"features"`
+	}{}
+	if err = json.Unmarshal(contents, &collection); err != nil {
+		t.Errorf("Output should be valid FeatureCollection: %s", err.Error())
+		return
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != 2 {
+		t.Errorf("FeatureCollection with 2 features is expected, but got type '%s' with %d features", collection.Type, len(collection.Features))
+	}
+
+	fname = filepath.Join(dir, "tracks.geojsonl")
+	writer, err = NewTrajectoryWriter(fname, TRAJECTORY_FORMAT_GEOJSONL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for i := 0; i < 3; i++ {
+		if err = writer.Write(&feature); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	writer.Close()
+	file, err := os.Open(fname)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parsed := GeoJSONFeature{}
+		if err = json.Unmarshal(scanner.Bytes(), &parsed); err != nil {
+			t.Errorf("Line %d should be valid feature: %s", lines, err.Error())
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Errorf("3 lines are expected, but got %d", lines)
+	}
+}
+
+func TestTrajectoryFeature(t *testing.T) {
+	start := time.Date(2021, 5, 1, 12, 0, 0, 0, time.UTC)
+	converter := SpatialConverter{
+		Type: MAPPER_TYPE_EPSG4326,
+		Function: func(pt gocv.Point2f) gocv.Point2f {
+			return gocv.Point2f{X: 37 + pt.X/100, Y: 55 + pt.Y/100}
+		},
+	}
+	b := blob.NewSimpleBlobie(image.Rect(0, 0, 10, 10), nil)
+	tr := blobTrajectory(b)
+	tr.addPoint(image.Point{X: 5, Y: 5}, start)
+	if _, ok := TrajectoryFeature(b, "cam", &converter); ok {
+		t.Errorf("Track with single point can't be LineString")
+	}
+	tr.addPoint(image.Point{X: 6, Y: 6}, start)
+	tr.addPoint(image.Point{X: 15, Y: 25}, start.Add(500*time.Millisecond))
+	tr.addSpeed(10, start)
+	tr.addSpeed(30, start.Add(500*time.Millisecond))
+	// Track has not gained new point (e.g. object has not been detected): estimation should be skipped
+	tr.addSpeed(30, start.Add(500*time.Millisecond))
+	tr.visitPolygon(3)
+	tr.visitPolygon(1)
+	tr.visitPolygon(3)
+
+	feature, ok := TrajectoryFeature(b, "cam", &converter)
+	if !ok {
+		t.Errorf("Feature should be prepared")
+		return
+	}
+	if len(feature.Geometry.Coordinates) != 2 {
+		t.Errorf("Point with the same timestamp should be skipped: 2 vertices are expected, but got %d", len(feature.Geometry.Coordinates))
+		return
+	}
+	if lon, lat := feature.Geometry.Coordinates[1][0], feature.Geometry.Coordinates[1][1]; math.Abs(lon-37.15) > 1e-4 || math.Abs(lat-55.25) > 1e-4 {
+		t.Errorf("Coordinates should be converted to [Longitude; Latitude], but got %v", feature.Geometry.Coordinates[1])
+	}
+	if feature.Properties.Timestamps[1]-feature.Properties.Timestamps[0] != 500 {
+		t.Errorf("Timestamps should be in milliseconds, but got %v", feature.Properties.Timestamps)
+	}
+	if feature.Properties.MeanSpeed == nil || *feature.Properties.MeanSpeed != 20 || *feature.Properties.MaxSpeed != 30 {
+		t.Errorf("Mean speed 20 and max speed 30 are expected")
+	}
+	if len(feature.Properties.Polygons) != 2 || feature.Properties.Polygons[0] != 1 || feature.Properties.Polygons[1] != 3 {
+		t.Errorf("Polygons [1 3] are expected, but got %v", feature.Properties.Polygons)
+	}
+
+	// GeoJSON requires WGS84 coordinates
+	if _, ok = TrajectoryFeature(b, "cam", NewIdentitySpatialConverter()); ok {
+		t.Errorf("Feature should not be prepared without calibration")
+	}
+	converter.Type = MAPPER_TYPE_METRIC
+	if _, ok = TrajectoryFeature(b, "cam", &converter); ok {
+		t.Errorf("Feature should not be prepared for metric coordinates")
+	}
+}
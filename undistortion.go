@@ -0,0 +1,114 @@
+package odam
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+
+	"gocv.io/x/gocv"
+)
+
+// UNDISTORTION_MODE Alias to int
+type UNDISTORTION_MODE int
+
+const (
+	// UNDISTORTION_MODE_FRAME Whole frame is undistorted before scaling, so detection, tracking and drawing work with undistorted image
+	UNDISTORTION_MODE_FRAME = UNDISTORTION_MODE(iota + 1)
+	// UNDISTORTION_MODE_TRACK_POINTS Only track points (and image points of mapper) are undistorted before spatial conversion. Cheaper, since frames are kept as is
+	UNDISTORTION_MODE_TRACK_POINTS
+)
+
+// String returns text representation of undistortion mode (as it is used in configuration file)
+func (um UNDISTORTION_MODE) String() string {
+	switch um {
+	case UNDISTORTION_MODE_FRAME:
+		return "frame"
+	case UNDISTORTION_MODE_TRACK_POINTS:
+		return "track_points"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(um))
+	}
+}
+
+// Undistorter Corrects lens distortion of frames or points
+// Undistorted image keeps camera matrix, so pixel coordinates of undistorted frame and undistorted points are the same
+type Undistorter struct {
+	cameraMatrix gocv.Mat
+	distCoeffs   gocv.Mat
+	// Maps for remapping of frames. They are prepared on first call of UndistortFrame(), since they are not needed when only track points are undistorted
+	map1     gocv.Mat
+	map2     gocv.Mat
+	mapsOnce sync.Once
+	// Size of source frame
+	size image.Point
+	// Scale factors from scaled coordinates to source ones
+	scaleX float64
+	scaleY float64
+}
+
+// NewUndistorter Constructor for Undistorter
+//
+// settings - camera intrinsics and distortion coefficients (for source resolution)
+// width, height - size of source frame
+// scaleX, scaleY - scale factors between source and scaled frames (see ref. VideoSettings.ScaleX and VideoSettings.ScaleY)
+//
+func NewUndistorter(settings *UndistortionSettings, width, height int, scaleX, scaleY float64) *Undistorter {
+	u := Undistorter{
+		cameraMatrix: gocv.NewMatWithSize(3, 3, gocv.MatTypeCV64F),
+		distCoeffs:   gocv.NewMatWithSize(1, len(settings.DistortionCoefficients), gocv.MatTypeCV64F),
+		map1:         gocv.NewMat(),
+		map2:         gocv.NewMat(),
+		size:         image.Point{X: width, Y: height},
+		scaleX:       scaleX,
+		scaleY:       scaleY,
+	}
+	for row := range settings.CameraMatrix {
+		for col := range settings.CameraMatrix[row] {
+			u.cameraMatrix.SetDoubleAt(row, col, settings.CameraMatrix[row][col])
+		}
+	}
+	for i, coefficient := range settings.DistortionCoefficients {
+		u.distCoeffs.SetDoubleAt(0, i, coefficient)
+	}
+	return &u
+}
+
+// Close Free memory for underlying matrices
+func (u *Undistorter) Close() {
+	u.cameraMatrix.Close()
+	u.distCoeffs.Close()
+	u.map1.Close()
+	u.map2.Close()
+}
+
+// UndistortFrame Corrects lens distortion of source frame
+// Remapping maps are prepared once on first call, so it is safe to call it concurrently
+func (u *Undistorter) UndistortFrame(src gocv.Mat, dst *gocv.Mat) {
+	u.mapsOnce.Do(u.prepareMaps)
+	gocv.Remap(src, dst, &u.map1, &u.map2, gocv.InterpolationLinear, gocv.BorderConstant, color.RGBA{})
+}
+
+// prepareMaps Computes maps for remapping of source frames
+func (u *Undistorter) prepareMaps() {
+	rectification := gocv.NewMat()
+	defer rectification.Close()
+	gocv.InitUndistortRectifyMap(u.cameraMatrix, u.distCoeffs, rectification, u.cameraMatrix, u.size, int(gocv.MatTypeCV32F), u.map1, u.map2)
+}
+
+// UndistortPoint Corrects lens distortion of point [scaled]
+func (u *Undistorter) UndistortPoint(pt gocv.Point2f) gocv.Point2f {
+	src := gocv.NewMatWithSize(1, 1, gocv.MatTypeCV64FC2)
+	defer src.Close()
+	src.SetDoubleAt(0, 0, float64(pt.X)*u.scaleX)
+	src.SetDoubleAt(0, 1, float64(pt.Y)*u.scaleY)
+	dst := gocv.NewMat()
+	defer dst.Close()
+	rectification := gocv.NewMat()
+	defer rectification.Close()
+	gocv.UndistortPoints(src, &dst, u.cameraMatrix, u.distCoeffs, rectification, u.cameraMatrix)
+	return gocv.Point2f{
+		X: float32(dst.GetDoubleAt(0, 0) / u.scaleX),
+		Y: float32(dst.GetDoubleAt(0, 1) / u.scaleY),
+	}
+}
@@ -0,0 +1,56 @@
+package odam
+
+import (
+	"math"
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+func TestUndistortionSettings(t *testing.T) {
+	us := UndistortionSettings{
+		CameraMatrix:           [3][3]float64{{1000, 0, 960}, {0, 1000, 540}, {0, 0, 1}},
+		DistortionCoefficients: []float64{-0.3, 0.1, 0, 0, 0},
+		Mode:                   "TRACK_POINTS",
+	}
+	if !us.Prepare() {
+		t.Errorf("Settings should be valid")
+	}
+	if us.GetModeType() != UNDISTORTION_MODE_TRACK_POINTS {
+		t.Errorf("Mode should be 'track_points', but got '%s'", us.GetModeType())
+	}
+	us = UndistortionSettings{
+		CameraMatrix:           [3][3]float64{{1000, 0, 960}, {0, 1000, 540}, {0, 0, 1}},
+		DistortionCoefficients: []float64{-0.3, 0.1},
+	}
+	if us.Prepare() {
+		t.Errorf("Settings with 2 distortion coefficients should not be valid")
+	}
+	us = UndistortionSettings{
+		DistortionCoefficients: []float64{-0.3, 0.1, 0, 0},
+	}
+	if us.Prepare() {
+		t.Errorf("Settings without camera matrix should not be valid")
+	}
+}
+
+func TestUndistortPoint(t *testing.T) {
+	us := UndistortionSettings{
+		CameraMatrix:           [3][3]float64{{1000, 0, 960}, {0, 1000, 540}, {0, 0, 1}},
+		DistortionCoefficients: []float64{-0.3, 0.1, 0, 0, 0},
+	}
+	us.Prepare()
+	// Source frame is 1920x1080, scaled one is 960x540
+	undistorter := NewUndistorter(&us, 1920, 1080, 2, 2)
+	defer undistorter.Close()
+	// Principal point is not affected by radial distortion
+	center := undistorter.UndistortPoint(gocv.Point2f{X: 480, Y: 270})
+	if math.Abs(float64(center.X)-480) > 1e-3 || math.Abs(float64(center.Y)-270) > 1e-3 {
+		t.Errorf("Principal point should not be changed, but got %v", center)
+	}
+	// Barrel distortion pulls points to the center, so undistorted point should be further from it
+	corner := undistorter.UndistortPoint(gocv.Point2f{X: 900, Y: 500})
+	if corner.X <= 900 || corner.Y <= 500 {
+		t.Errorf("Undistorted point should be further from the center than (900, 500), but got %v", corner)
+	}
+}
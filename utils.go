@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/jpeg"
 	"math"
+	"time"
 
 	blob "github.com/LdDl/gocv-blob/v2/blob"
 	"github.com/pkg/errors"
@@ -25,6 +26,37 @@ func minInt(x, y int) int {
 	return y
 }
 
+// blobSpeed Returns estimated speed of object (if it has been estimated)
+func blobSpeed(b blob.Blobie) (float32, bool) {
+	spdInterface, ok := b.GetProperty("speed")
+	if !ok {
+		return 0, false
+	}
+	spd, ok := spdInterface.(float32)
+	return spd, ok
+}
+
+const (
+	blobEstimatedAtProperty = "speed_estimated_at"
+)
+
+// blobTrackUpdated Checks if track of object has gained new point since previous call (e.g. object has not been matched on current frame otherwise)
+// Timestamp of the last point is remembered in blob's properties
+func blobTrackUpdated(b blob.Blobie) bool {
+	timestamps := b.GetTimestamps()
+	if len(timestamps) == 0 {
+		return false
+	}
+	last := timestamps[len(timestamps)-1]
+	if prop, ok := b.GetProperty(blobEstimatedAtProperty); ok {
+		if previous, ok := prop.(time.Time); ok && !last.After(previous) {
+			return false
+		}
+	}
+	b.SetProperty(blobEstimatedAtProperty, last)
+	return true
+}
+
 func stringInSlice(str *string, sl []string) bool {
 	for i := range sl {
 		if sl[i] == *str {
@@ -117,14 +149,70 @@ func DetectionInfoGRPC(xmin, ymin, width, height int32) *Detection {
 }
 
 // VirtualLineInfoGRPC Prepares gRPC message 'VirtualLineInfo'
-// Identifier of a line (int64) and its parameters (x0,y0 and x1,y1) should be provide
+// Identifier of a line (int64) and its parameters (x0,y0 and x1,y1; vertices for polyline) should be provide
 func VirtualLineInfoGRPC(lineID int64, virtualLine *VirtualLine) *VirtualLineInfo {
+	points := make([]*EuclideanPoint, len(virtualLine.SourcePoints))
+	for i, pt := range virtualLine.SourcePoints {
+		points[i] = &EuclideanPoint{
+			X: float32(pt.X),
+			Y: float32(pt.Y),
+		}
+	}
 	return &VirtualLineInfo{
 		Id:     lineID,
 		LeftX:  int32(virtualLine.SourceLeftPT.X),
 		LeftY:  int32(virtualLine.SourceLeftPT.Y),
 		RightX: int32(virtualLine.SourceRightPT.X),
 		RightY: int32(virtualLine.SourceRightPT.Y),
+		Points: points,
+	}
+}
+
+// LineDirectionGRPC Converts direction of crossing to gRPC enum 'LineDirection'
+func LineDirectionGRPC(direction LINE_DIRECTION) LineDirection {
+	switch direction {
+	case LINE_DIRECTION_TO_DETECTOR:
+		return LineDirection_LINE_DIRECTION_TO_DETECTOR
+	case LINE_DIRECTION_FROM_DETECTOR:
+		return LineDirection_LINE_DIRECTION_FROM_DETECTOR
+	default:
+		return LineDirection_LINE_DIRECTION_UNDEFINED
+	}
+}
+
+// VirtualPolygonInfoGRPC Prepares gRPC message 'VirtualPolygonInfo'
+// Identifier of a polygon (int64), polygon itself (non-scaled coordinates are used) and type of event should be provided
+func VirtualPolygonInfoGRPC(polygonID int64, virtualPolygon *VirtualPolygon, eventType PolygonEventType) *VirtualPolygonInfo {
+	coordinates := make([]*EuclideanPoint, len(virtualPolygon.SourceCoordinates))
+	for i, pt := range virtualPolygon.SourceCoordinates {
+		coordinates[i] = &EuclideanPoint{
+			X: float32(pt.X),
+			Y: float32(pt.Y),
+		}
+	}
+	return &VirtualPolygonInfo{
+		Id:          polygonID,
+		Coordinates: coordinates,
+		EventType:   eventType,
+	}
+}
+
+// SpeedViolationInfoGRPC Prepares gRPC message 'SpeedViolationInfo'
+// Exceeded limit, its source (zone or class), speed estimations which have triggered violation and bytes of full-resolution frame should be provided
+func SpeedViolationInfoGRPC(limit float32, zoneLimit bool, polygonID int64, records []SpeedRecord, fullImage []byte) *SpeedViolationInfo {
+	samples := make([]*SpeedSample, len(records))
+	for i, record := range records {
+		samples[i] = &SpeedSample{
+			Timestamp: record.Timestamp.UTC().UnixNano() / int64(time.Millisecond),
+			Speed:     record.Speed,
+		}
+	}
+	return &SpeedViolationInfo{
+		SpeedLimit: limit,
+		ZoneLimit:  zoneLimit,
+		PolygonId:  polygonID,
+		Samples:    samples,
+		FullImage:  fullImage,
 	}
 }
 
@@ -133,8 +221,8 @@ func VirtualLineInfoGRPC(lineID int64, virtualLine *VirtualLine) *VirtualLineInf
 // Blob object for track extraction
 // Key for extracting speed infromation
 // Width/Height scale for EuclideanPoint correction to actual coordinates
-// Coverter function (from pixel to WGS84)
-func TrackInfoInfoGRPC(b blob.Blobie, speedKey string, scalex, scaley float32, gisConverter func(gocv.Point2f) gocv.Point2f) *TrackInfo {
+// Coverter (from pixel to WGS84 or to local metric coordinates). If it is nil or identity one then only Euclidean points are provided
+func TrackInfoInfoGRPC(b blob.Blobie, speedKey string, scalex, scaley float32, converter *SpatialConverter) *TrackInfo {
 	// Extract estimated speed information
 	spd := float32(0.0)
 	if spdInterface, ok := b.GetProperty(speedKey); ok {
@@ -157,19 +245,30 @@ func TrackInfoInfoGRPC(b blob.Blobie, speedKey string, scalex, scaley float32, g
 	trackPixels := b.GetTrack()
 	trackUnionInfo := make([]*Point, len(trackPixels))
 	for i, stdPt := range trackPixels {
-		// Convert point to spatial representation via provided converter function
 		cvPt := STDPointToGoCVPoint2F(stdPt)
-		gisPt := gisConverter(cvPt)
 		// Collect point information
 		trackUnionInfo[i] = &Point{
 			EuclideanPoint: &EuclideanPoint{
 				X: cvPt.X * scalex,
 				Y: cvPt.Y * scaley,
 			},
-			Wgs84Point: &WGS84Point{
+		}
+		// There are only Euclidean points when there is no calibration
+		if converter.IsIdentity() {
+			continue
+		}
+		// Convert point to spatial representation via provided converter function
+		gisPt := converter.Function(cvPt)
+		if converter.Type == MAPPER_TYPE_METRIC {
+			trackUnionInfo[i].MetricPoint = &EuclideanPoint{
+				X: gisPt.X,
+				Y: gisPt.Y,
+			}
+		} else {
+			trackUnionInfo[i].Wgs84Point = &WGS84Point{
 				Longitude: gisPt.X,
 				Latitude:  gisPt.Y,
-			},
+			}
 		}
 	}
 	return &TrackInfo{
@@ -1,9 +1,12 @@
 package odam
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"math"
+	"sort"
+	"sync/atomic"
 
 	blob "github.com/LdDl/gocv-blob/v2/blob"
 	"gocv.io/x/gocv"
@@ -17,10 +20,47 @@ const (
 	HORIZONTAL_LINE = VIRTUAL_LINE_TYPE(iota + 1)
 	// OBLIQUE_LINE Represents the line with Y{1} of (X{1}Y{1}) <> Y{2} of (X{2}Y{2}) (so it has some angle)
 	OBLIQUE_LINE
+	// POLYLINE Represents the line which consists of several segments (X{1}Y{1}) -> (X{2}Y{2}) -> ... -> (X{N}Y{N})
+	POLYLINE
 )
 
+// LINE_DIRECTION Alias to int
+type LINE_DIRECTION int
+
+const (
+	// LINE_DIRECTION_TO_DETECTOR Object is moving to us ('Direction' = true)
+	LINE_DIRECTION_TO_DETECTOR = LINE_DIRECTION(iota + 1)
+	// LINE_DIRECTION_FROM_DETECTOR Object is moving from us ('Direction' = false)
+	LINE_DIRECTION_FROM_DETECTOR
+)
+
+// String returns text representation of direction (as it is used in configuration file)
+func (direction LINE_DIRECTION) String() string {
+	switch direction {
+	case LINE_DIRECTION_TO_DETECTOR:
+		return "to_detector"
+	case LINE_DIRECTION_FROM_DETECTOR:
+		return "from_detector"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(direction))
+	}
+}
+
+// lineDirectionFromBool Converts boolean direction (see ref. VirtualLine.Direction) to LINE_DIRECTION
+func lineDirectionFromBool(direction bool) LINE_DIRECTION {
+	if direction {
+		return LINE_DIRECTION_TO_DETECTOR
+	}
+	return LINE_DIRECTION_FROM_DETECTOR
+}
+
 // VirtualLine Detection line attributes
 type VirtualLine struct {
+	// Number of registered crossings for each direction: [to detector, from detector]
+	// Keep it first for 64-bit alignment of atomic operations
+	crossingsCount [2]uint64
+	// Identifier of line. It is used for remembering which lines have been crossed by object already, so it should be unique
+	ID int64 `json:"-"`
 	// Point on the left [scaled]
 	LeftPT image.Point `json:"-"`
 	// Point on the right [scaled]
@@ -29,12 +69,18 @@ type VirtualLine struct {
 	Color color.RGBA `json:"-"`
 	// Direction of traffic flow
 	Direction bool `json:"-"`
+	// Are crossings in both directions should be detected? If it is true then 'Direction' is ignored
+	Bidirectional bool `json:"-"`
 	// Is crossing object should be cropped for futher work with it?
 	CropObject bool `json:"-"`
 	// Point on the left [non-scaled]
 	SourceLeftPT image.Point `json:"-"`
 	// Point on the right [non-scaled]
 	SourceRightPT image.Point `json:"-"`
+	// Vertices of polyline [scaled]. First and last ones are the same as LeftPT and RightPT
+	Points []image.Point `json:"-"`
+	// Vertices of polyline [non-scaled]
+	SourcePoints []image.Point `json:"-"`
 	// Type of virtual line: could be horizontal or oblique
 	LineType VIRTUAL_LINE_TYPE `json:"-"`
 }
@@ -48,6 +94,8 @@ func NewVirtualLine(x1, y1, x2, y2 int) *VirtualLine {
 		RightPT:       image.Point{X: x2, Y: y2},
 		SourceLeftPT:  image.Point{X: x1, Y: y1},
 		SourceRightPT: image.Point{X: x2, Y: y2},
+		Points:        []image.Point{{X: x1, Y: y1}, {X: x2, Y: y2}},
+		SourcePoints:  []image.Point{{X: x1, Y: y1}, {X: x2, Y: y2}},
 		Direction:     true,
 	}
 	if y1 == y2 {
@@ -58,35 +106,226 @@ func NewVirtualLine(x1, y1, x2, y2 int) *VirtualLine {
 	return &vline
 }
 
+// NewVirtualPolyline Constructor for VirtualLine which consists of several segments
+// points - vertices of polyline. At least two points should be provided. For exactly two points it is the same as NewVirtualLine()
+// Notice: direction of crossing is evaluated for each segment independently (see ref. segmentCrossingDirection())
+func NewVirtualPolyline(points ...image.Point) *VirtualLine {
+	n := len(points)
+	if n == 2 {
+		return NewVirtualLine(points[0].X, points[0].Y, points[1].X, points[1].Y)
+	}
+	vline := VirtualLine{
+		LeftPT:        points[0],
+		RightPT:       points[n-1],
+		SourceLeftPT:  points[0],
+		SourceRightPT: points[n-1],
+		Points:        make([]image.Point, n),
+		SourcePoints:  make([]image.Point, n),
+		Direction:     true,
+		LineType:      POLYLINE,
+	}
+	copy(vline.Points, points)
+	copy(vline.SourcePoints, points)
+	return &vline
+}
+
 // Scale Scales down (so scale factor can be > 1.0 ) virtual line
 // (scaleX, scaleY) - How to scale source (x1,y1) and (x2,y2) coordinates
 // Important notice:
 // 1. Source coordinates won't be modified
-// 2. Source coordinates would be used for scaling. So you can't scale line multiple times
+// 2. Source coordinates would be used for scaling. So line could be scaled multiple times and result depends on the last call only
 func (vline *VirtualLine) Scale(scaleX, scaleY float64) {
 	vline.LeftPT.X = int(math.Round(float64(vline.SourceLeftPT.X) / scaleX))
 	vline.LeftPT.Y = int(math.Round(float64(vline.SourceLeftPT.Y) / scaleY))
 	vline.RightPT.X = int(math.Round(float64(vline.SourceRightPT.X) / scaleX))
 	vline.RightPT.Y = int(math.Round(float64(vline.SourceRightPT.Y) / scaleY))
+	vline.Points = make([]image.Point, len(vline.SourcePoints))
+	for i := range vline.SourcePoints {
+		vline.Points[i].X = int(math.Round(float64(vline.SourcePoints[i].X) / scaleX))
+		vline.Points[i].Y = int(math.Round(float64(vline.SourcePoints[i].Y) / scaleY))
+	}
+}
+
+// segments Returns segments of line [scaled]
+func (vline *VirtualLine) segments() [][2]image.Point {
+	if vline.LineType != POLYLINE {
+		return [][2]image.Point{{vline.LeftPT, vline.RightPT}}
+	}
+	segments := make([][2]image.Point, 0, len(vline.Points)-1)
+	for i := 1; i < len(vline.Points); i++ {
+		segments = append(segments, [2]image.Point{vline.Points[i-1], vline.Points[i]})
+	}
+	return segments
+}
+
+// IntersectsRect Checks if any segment of line intersects given rectangle (e.g. bounding box of object) [scaled]
+func (vline *VirtualLine) IntersectsRect(rect image.Rectangle) bool {
+	corners := []image.Point{
+		rect.Min,
+		{X: rect.Max.X, Y: rect.Min.Y},
+		rect.Max,
+		{X: rect.Min.X, Y: rect.Max.Y},
+	}
+	for _, segment := range vline.segments() {
+		if segment[0].In(rect) || segment[1].In(rect) {
+			return true
+		}
+		for i := range corners {
+			corner, next := corners[i], corners[(i+1)%len(corners)]
+			if isIntersects(segment[0].X, segment[0].Y, segment[1].X, segment[1].Y, corner.X, corner.Y, next.X, next.Y) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // Draw Draw virtual line on image
+// Number of registered crossings is drawn near the left point of line
 func (vline *VirtualLine) Draw(img *gocv.Mat) {
-	gocv.Line(img, vline.LeftPT, vline.RightPT, vline.Color, 3)
+	for _, segment := range vline.segments() {
+		gocv.Line(img, segment[0], segment[1], vline.Color, 3)
+	}
+	text := ""
+	if vline.Bidirectional {
+		text = fmt.Sprintf("to: %d, from: %d", vline.CrossingsCount(LINE_DIRECTION_TO_DETECTOR), vline.CrossingsCount(LINE_DIRECTION_FROM_DETECTOR))
+	} else {
+		text = fmt.Sprintf("count: %d", vline.CrossingsCount(lineDirectionFromBool(vline.Direction)))
+	}
+	gocv.PutText(img, text, image.Point{X: vline.LeftPT.X, Y: vline.LeftPT.Y - 5}, gocv.FontHersheySimplex, 0.5, vline.Color, 1)
 }
 
-// IsBlobCrossedLine Wrapper around b.IsCrossedTheLine(y2,x1,y1,direction) and b.IsCrossedTheObliqueLine(x2,y2,x1,y1,direction).
-// See ref. https://github.com/LdDl/gocv-blob/blob/master/v2/blob/line_cross.go
+// IsBlobCrossedLine Checks if object has crossed the line (in any of allowed directions)
+// See ref. BlobCrossingDirection()
 func (vline *VirtualLine) IsBlobCrossedLine(b blob.Blobie) bool {
-	switch vline.LineType {
-	case HORIZONTAL_LINE:
-		return b.IsCrossedTheLine(vline.RightPT.Y, vline.LeftPT.X, vline.RightPT.X, vline.Direction)
-	case OBLIQUE_LINE:
-		return b.IsCrossedTheObliqueLine(vline.RightPT.X, vline.RightPT.Y, vline.LeftPT.X, vline.LeftPT.Y, vline.Direction)
+	_, crossed := vline.BlobCrossingDirection(b)
+	return crossed
+}
+
+// BlobCrossingDirection Checks if object has crossed the line and returns direction of crossing
+// Crossing is evaluated by intersection of the last movement of object with each segment of line.
+// Movement starts at the last point of track which does not lie on the line, so touching of line (and retreating back) is not a crossing,
+// while passing through the line via touching point is counted once the object reaches the other side.
+// For bidirectional line both directions are accepted, otherwise only 'Direction' is.
+// Each line reports the object only once: crossed line is remembered in blob's properties (see ref. BlobCrossedLinesIDs()),
+// so the same object could be counted by several lines
+func (vline *VirtualLine) BlobCrossingDirection(b blob.Blobie) (LINE_DIRECTION, bool) {
+	track := b.GetTrack()
+	trackLen := len(track)
+	if trackLen < 2 {
+		return 0, false
+	}
+	crossedLines := blobCrossedLines(b)
+	if _, ok := crossedLines[vline.ID]; ok {
+		return 0, false
+	}
+	current := track[trackLen-1]
+	for _, segment := range vline.segments() {
+		prev, ok := lastPointOffLine(segment[0], segment[1], track[:trackLen-1])
+		if !ok {
+			continue
+		}
+		direction, crossed := segmentCrossingDirection(segment[0], segment[1], prev, current)
+		if !crossed {
+			continue
+		}
+		if !vline.Bidirectional && direction != lineDirectionFromBool(vline.Direction) {
+			continue
+		}
+		crossedLines[vline.ID] = struct{}{}
+		return direction, true
+	}
+	return 0, false
+}
+
+// RegisterCrossing Increments counter of crossings for given direction. It is safe to call it concurrently
+func (vline *VirtualLine) RegisterCrossing(direction LINE_DIRECTION) {
+	switch direction {
+	case LINE_DIRECTION_TO_DETECTOR:
+		atomic.AddUint64(&vline.crossingsCount[0], 1)
+	case LINE_DIRECTION_FROM_DETECTOR:
+		atomic.AddUint64(&vline.crossingsCount[1], 1)
+	}
+}
+
+// CrossingsCount Returns number of registered crossings for given direction
+func (vline *VirtualLine) CrossingsCount(direction LINE_DIRECTION) uint64 {
+	switch direction {
+	case LINE_DIRECTION_TO_DETECTOR:
+		return atomic.LoadUint64(&vline.crossingsCount[0])
+	case LINE_DIRECTION_FROM_DETECTOR:
+		return atomic.LoadUint64(&vline.crossingsCount[1])
+	}
+	return 0
+}
+
+// segmentCrossingDirection Checks if movement of object from previous to current position intersects segment (a, b)
+// Returns direction of movement: moving to the side below the segment (Y axis points down in image) means moving to the detector.
+// For vertical segment moving to the left means moving to the detector.
+// Notice: both positions should lie strictly on different sides of the segment: position on the line is not a crossing (see ref. lastPointOffLine())
+func segmentCrossingDirection(a, b, prev, current image.Point) (LINE_DIRECTION, bool) {
+	// Make direction independent of order of vertices
+	if a.X > b.X || (a.X == b.X && a.Y > b.Y) {
+		a, b = b, a
+	}
+	// Counterclockwise orientation of (a, b, p) means that point p lies below the directed segment (a -> b) in image coordinates
+	sidePrev := getOrientation(a.X, a.Y, b.X, b.Y, prev.X, prev.Y)
+	sideCurrent := getOrientation(a.X, a.Y, b.X, b.Y, current.X, current.Y)
+	var direction LINE_DIRECTION
+	switch {
+	case sidePrev == Clockwise && sideCurrent == CounterClockwise:
+		direction = LINE_DIRECTION_TO_DETECTOR
+	case sidePrev == CounterClockwise && sideCurrent == Clockwise:
+		direction = LINE_DIRECTION_FROM_DETECTOR
 	default:
-		// This actually should not happen
-		// Is this really needed to have error returning in this function?
-		break
+		return 0, false
 	}
-	return false
+	// Movement should intersect the segment itself, not just the infinite line through it
+	sideA := getOrientation(prev.X, prev.Y, current.X, current.Y, a.X, a.Y)
+	sideB := getOrientation(prev.X, prev.Y, current.X, current.Y, b.X, b.Y)
+	if sideA != Collinear && sideA == sideB {
+		return 0, false
+	}
+	return direction, true
+}
+
+// lastPointOffLine Returns the last point of track which does not lie on the infinite line through segment (a, b)
+// Returns false if every point lies on the line
+func lastPointOffLine(a, b image.Point, track []image.Point) (image.Point, bool) {
+	for i := len(track) - 1; i >= 0; i-- {
+		if getOrientation(a.X, a.Y, b.X, b.Y, track[i].X, track[i].Y) != Collinear {
+			return track[i], true
+		}
+	}
+	return image.Point{}, false
+}
+
+const (
+	blobLinesProperty = "crossed_lines"
+)
+
+// blobCrossedLines Returns set of identifiers of lines which have been crossed by the object already
+// Set is stored in blob's properties, so it is created on first call
+func blobCrossedLines(b blob.Blobie) map[int64]struct{} {
+	if prop, ok := b.GetProperty(blobLinesProperty); ok {
+		if lines, ok := prop.(map[int64]struct{}); ok {
+			return lines
+		}
+	}
+	lines := make(map[int64]struct{})
+	b.SetProperty(blobLinesProperty, lines)
+	return lines
+}
+
+// BlobCrossedLinesIDs Returns sorted identifiers of lines which have been crossed by the object (according to BlobCrossingDirection() calls)
+func BlobCrossedLinesIDs(b blob.Blobie) []int64 {
+	lines := blobCrossedLines(b)
+	ids := make([]int64, 0, len(lines))
+	for id := range lines {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
 }
@@ -132,3 +132,222 @@ func TestLineCross(t *testing.T) {
 		}
 	}
 }
+
+func TestLineCrossBothDirections(t *testing.T) {
+	vline := NewVirtualLine(4, 35, 73, 35)
+	vline.Bidirectional = true
+	tracks := []struct {
+		rects     []image.Rectangle
+		direction LINE_DIRECTION
+	}{
+		{
+			// Moving to us
+			[]image.Rectangle{image.Rect(26, 8, 44, 18), image.Rect(26, 20, 44, 30), image.Rect(26, 32, 44, 42)},
+			LINE_DIRECTION_TO_DETECTOR,
+		},
+		{
+			// Moving from us
+			[]image.Rectangle{image.Rect(26, 32, 44, 42), image.Rect(26, 20, 44, 30), image.Rect(26, 8, 44, 18)},
+			LINE_DIRECTION_FROM_DETECTOR,
+		},
+	}
+	for i, track := range tracks {
+		allblobies := blob.NewBlobiesDefaults()
+		for _, rect := range track.rects {
+			allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(rect, nil)})
+		}
+		for _, b := range allblobies.Objects {
+			direction, crossed := vline.BlobCrossingDirection(b)
+			if !crossed {
+				t.Errorf("#%d Line should be crossed by track %v", i+1, b.GetTrack())
+				continue
+			}
+			if direction != track.direction {
+				t.Errorf("#%d Line should be crossed in direction '%s', but got '%s'", i+1, track.direction, direction)
+			}
+			vline.RegisterCrossing(direction)
+		}
+	}
+	if vline.CrossingsCount(LINE_DIRECTION_TO_DETECTOR) != 1 || vline.CrossingsCount(LINE_DIRECTION_FROM_DETECTOR) != 1 {
+		t.Errorf("Line should have 1 crossing in each direction, but got %d (to detector) and %d (from detector)", vline.CrossingsCount(LINE_DIRECTION_TO_DETECTOR), vline.CrossingsCount(LINE_DIRECTION_FROM_DETECTOR))
+	}
+}
+
+func TestPolylineCross(t *testing.T) {
+	vline := NewVirtualPolyline(image.Point{X: 4, Y: 35}, image.Point{X: 40, Y: 35}, image.Point{X: 73, Y: 45})
+	if vline.LineType != POLYLINE {
+		t.Errorf("Line should be of type '%d' but got %d", POLYLINE, vline.LineType)
+	}
+	tracks := [][]image.Rectangle{
+		// Crosses horizontal segment
+		{image.Rect(26, 8, 44, 18), image.Rect(26, 20, 44, 30), image.Rect(26, 32, 44, 42)},
+		// Crosses oblique segment
+		{image.Rect(51, 20, 69, 30), image.Rect(51, 32, 69, 42), image.Rect(51, 44, 69, 54)},
+	}
+	for i, track := range tracks {
+		allblobies := blob.NewBlobiesDefaults()
+		for _, rect := range track {
+			allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(rect, nil)})
+		}
+		for _, b := range allblobies.Objects {
+			if !vline.IsBlobCrossedLine(b) {
+				t.Errorf("#%d Polyline should be crossed by track %v", i+1, b.GetTrack())
+			}
+			// Only one event per object per line
+			if vline.IsBlobCrossedLine(b) {
+				t.Errorf("#%d Polyline should not be crossed twice by track %v", i+1, b.GetTrack())
+			}
+		}
+	}
+}
+
+func TestPolylineScale(t *testing.T) {
+	vline := NewVirtualPolyline(image.Point{X: 100, Y: 300}, image.Point{X: 400, Y: 350}, image.Point{X: 800, Y: 300})
+	vline.Scale(2.0, 2.0)
+	vline.Scale(2.0, 2.0)
+	expected := []image.Point{{X: 50, Y: 150}, {X: 200, Y: 175}, {X: 400, Y: 150}}
+	if len(vline.Points) != len(expected) {
+		t.Errorf("Polyline should have %d points, but got %d", len(expected), len(vline.Points))
+		return
+	}
+	for i := range expected {
+		if vline.Points[i] != expected[i] {
+			t.Errorf("Point #%d should be %v, but got %v", i, expected[i], vline.Points[i])
+		}
+	}
+	if vline.LeftPT != expected[0] || vline.RightPT != expected[len(expected)-1] {
+		t.Errorf("Left and right points should be %v and %v, but got %v and %v", expected[0], expected[len(expected)-1], vline.LeftPT, vline.RightPT)
+	}
+	if len(vline.segments()) != 2 {
+		t.Errorf("Polyline should have 2 segments, but got %d", len(vline.segments()))
+	}
+}
+
+func TestSegmentCrossingDirection(t *testing.T) {
+	cases := []struct {
+		a, b, prev, current image.Point
+		direction           LINE_DIRECTION
+		crossed             bool
+	}{
+		// Horizontal segment: moving down and up
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 5, Y: 5}, image.Point{X: 5, Y: 15}, LINE_DIRECTION_TO_DETECTOR, true},
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 5, Y: 15}, image.Point{X: 5, Y: 5}, LINE_DIRECTION_FROM_DETECTOR, true},
+		// Order of vertices does not matter
+		{image.Point{X: 20, Y: 10}, image.Point{X: 0, Y: 10}, image.Point{X: 5, Y: 5}, image.Point{X: 5, Y: 15}, LINE_DIRECTION_TO_DETECTOR, true},
+		// Movement crosses infinite line, but not the segment
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 25, Y: 5}, image.Point{X: 25, Y: 15}, 0, false},
+		// Movement does not reach the segment
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 5, Y: 0}, image.Point{X: 5, Y: 9}, 0, false},
+		// Oblique segment
+		{image.Point{X: 0, Y: 0}, image.Point{X: 20, Y: 20}, image.Point{X: 15, Y: 5}, image.Point{X: 5, Y: 15}, LINE_DIRECTION_TO_DETECTOR, true},
+		// Current position on the segment is not a crossing yet
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 5, Y: 5}, image.Point{X: 5, Y: 10}, 0, false},
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 5, Y: 15}, image.Point{X: 5, Y: 10}, 0, false},
+		// Previous position on the segment is not a crossing in both directions (movement should start off the line)
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 5, Y: 10}, image.Point{X: 5, Y: 15}, 0, false},
+		{image.Point{X: 0, Y: 10}, image.Point{X: 20, Y: 10}, image.Point{X: 5, Y: 10}, image.Point{X: 5, Y: 5}, 0, false},
+	}
+	for i, c := range cases {
+		direction, crossed := segmentCrossingDirection(c.a, c.b, c.prev, c.current)
+		if crossed != c.crossed || direction != c.direction {
+			t.Errorf("#%d Segment %v-%v and movement %v-%v: expected (%s, %t), but got (%s, %t)", i+1, c.a, c.b, c.prev, c.current, c.direction, c.crossed, direction, crossed)
+		}
+	}
+}
+
+func TestSeveralLinesCross(t *testing.T) {
+	vlines := []*VirtualLine{
+		NewVirtualLine(4, 35, 73, 35),
+		NewVirtualLine(4, 47, 73, 47),
+	}
+	for i, vline := range vlines {
+		vline.ID = int64(i + 1)
+	}
+	allblobies := blob.NewBlobiesDefaults()
+	rects := []image.Rectangle{image.Rect(26, 20, 44, 30), image.Rect(26, 32, 44, 42), image.Rect(26, 44, 44, 54), image.Rect(26, 56, 44, 66)}
+	crossings := make([]int, len(vlines))
+	for _, rect := range rects {
+		allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(rect, nil)})
+		for _, b := range allblobies.Objects {
+			for i, vline := range vlines {
+				if vline.IsBlobCrossedLine(b) {
+					crossings[i]++
+				}
+				// Second check for the same position should not count object again
+				if vline.IsBlobCrossedLine(b) {
+					crossings[i]++
+				}
+			}
+		}
+	}
+	for i := range vlines {
+		if crossings[i] != 1 {
+			t.Errorf("Line #%d should count object exactly once, but got %d", i+1, crossings[i])
+		}
+	}
+	for _, b := range allblobies.Objects {
+		ids := BlobCrossedLinesIDs(b)
+		if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+			t.Errorf("Object should have crossed lines [1 2], but got %v", ids)
+		}
+	}
+}
+
+func TestLineIntersectsRect(t *testing.T) {
+	vline := NewVirtualPolyline(image.Point{X: 0, Y: 50}, image.Point{X: 100, Y: 50}, image.Point{X: 200, Y: 0})
+	rects := []image.Rectangle{
+		image.Rect(40, 40, 60, 60),   // Crossed by horizontal segment
+		image.Rect(140, 10, 160, 40), // Crossed by oblique segment
+		image.Rect(40, 60, 60, 80),   // Below the line
+		image.Rect(160, 50, 190, 80), // Below oblique segment
+	}
+	expected := []bool{true, true, false, false}
+	for i, rect := range rects {
+		if vline.IntersectsRect(rect) != expected[i] {
+			t.Errorf("#%d Rectangle %v: intersection with line should be %t", i+1, rect, expected[i])
+		}
+	}
+}
+
+func TestLineTouch(t *testing.T) {
+	// Horizontal line at Y = 50. Centers of rectangles are used as track points
+	vline := NewVirtualLine(0, 50, 100, 50)
+	vline.Bidirectional = true
+	above, onLine, below := image.Rect(41, 33, 59, 43), image.Rect(41, 45, 59, 55), image.Rect(41, 57, 59, 67)
+	cases := []struct {
+		name      string
+		rects     []image.Rectangle
+		crossings []bool
+		direction LINE_DIRECTION
+	}{
+		{
+			// From below: touch the line and go back down, then cross it for real
+			name:      "from detector: touch and retreat",
+			rects:     []image.Rectangle{below, onLine, below, onLine, above},
+			crossings: []bool{false, false, false, false, true},
+			direction: LINE_DIRECTION_FROM_DETECTOR,
+		},
+		{
+			// From above: touch the line and go back up, then cross it for real
+			name:      "to detector: touch and retreat",
+			rects:     []image.Rectangle{above, onLine, above, onLine, below},
+			crossings: []bool{false, false, false, false, true},
+			direction: LINE_DIRECTION_TO_DETECTOR,
+		},
+	}
+	for _, c := range cases {
+		allblobies := blob.NewBlobiesDefaults()
+		for i, rect := range c.rects {
+			allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(rect, nil)})
+			for _, b := range allblobies.Objects {
+				direction, crossed := vline.BlobCrossingDirection(b)
+				if crossed != c.crossings[i] {
+					t.Errorf("%s: step #%d crossing should be %t, but got %t", c.name, i, c.crossings[i], crossed)
+				}
+				if crossed && direction != c.direction {
+					t.Errorf("%s: step #%d direction should be %s, but got %s", c.name, i, c.direction, direction)
+				}
+			}
+		}
+	}
+}
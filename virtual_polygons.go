@@ -1,9 +1,13 @@
 package odam
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"math"
+	"sort"
+	"sync"
+	"time"
 
 	blob "github.com/LdDl/gocv-blob/v2/blob"
 	"gocv.io/x/gocv"
@@ -21,12 +25,48 @@ const (
 	CONCAVE_POLYGON
 )
 
+// POLYGON_ANCHOR Alias to int
+type POLYGON_ANCHOR int
+
+const (
+	// POLYGON_ANCHOR_CENTER Object is inside of polygon when center of its bounding box is inside
+	POLYGON_ANCHOR_CENTER = POLYGON_ANCHOR(iota + 1)
+	// POLYGON_ANCHOR_BOTTOM_CENTER Object is inside of polygon when bottom center of its bounding box (ground contact point) is inside
+	POLYGON_ANCHOR_BOTTOM_CENTER
+	// POLYGON_ANCHOR_ANY_CORNER Object is inside of polygon when any corner of its bounding box is inside
+	POLYGON_ANCHOR_ANY_CORNER
+	// POLYGON_ANCHOR_OVERLAP Object is inside of polygon when fraction of its bounding box area covered by polygon is not less than MinOverlap
+	POLYGON_ANCHOR_OVERLAP
+)
+
+// String returns text representation of anchor (as it is used in configuration file)
+func (anchor POLYGON_ANCHOR) String() string {
+	switch anchor {
+	case POLYGON_ANCHOR_CENTER:
+		return "center"
+	case POLYGON_ANCHOR_BOTTOM_CENTER:
+		return "bottom_center"
+	case POLYGON_ANCHOR_ANY_CORNER:
+		return "any_corner"
+	case POLYGON_ANCHOR_OVERLAP:
+		return "overlap"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(anchor))
+	}
+}
+
 // VirtualPolygon Detection polygon attributes
 type VirtualPolygon struct {
 	// Polygon's identifier (inherited by wrapping structure)
 	ID int64 `json:"-"`
 	// Color of stroke line
 	Color color.RGBA `json:"-"`
+	// Is object should be cropped for futher work with it when it has entered or left polygon?
+	CropObject bool `json:"-"`
+	// What part of object's bounding box is used to check if object is inside of polygon. Zero value is treated as POLYGON_ANCHOR_CENTER
+	Anchor POLYGON_ANCHOR `json:"-"`
+	// Minimum fraction of bounding box area covered by polygon. Used with POLYGON_ANCHOR_OVERLAP only
+	MinOverlap float64 `json:"-"`
 	// Information about coordinates [scaled]
 	Coordinates []image.Point `json:"-"`
 	// Information about coordinates [non-scaled]
@@ -37,6 +77,33 @@ type VirtualPolygon struct {
 
 	gocvPoly     gocv.PointVector
 	gocvPolyDraw gocv.PointsVector
+	// Guards scaled coordinates and native vectors, so polygon could be rescaled at runtime while tracker and drawing use it
+	geometryMutex sync.RWMutex
+
+	// Objects which are inside of polygon at the moment (keyed by blob's identifier)
+	visits      map[string]*PolygonVisit
+	visitsMutex sync.Mutex
+}
+
+// PolygonVisit Information about object's stay in polygon
+type PolygonVisit struct {
+	// Identifier of object
+	BlobID string
+	// Class of object
+	ClassName string
+	// When object has entered polygon
+	EnteredAt time.Time
+	// When object has left polygon (or has been lost by tracker). Zero value means that object is still inside
+	LeftAt time.Time
+}
+
+// Dwell Returns time spent in polygon
+// For object which is still inside time is evaluated up to provided moment
+func (visit *PolygonVisit) Dwell(now time.Time) time.Duration {
+	if !visit.LeftAt.IsZero() {
+		return visit.LeftAt.Sub(visit.EnteredAt)
+	}
+	return now.Sub(visit.EnteredAt)
 }
 
 // Constructor for VirtualPolygon
@@ -47,6 +114,7 @@ func NewVirtualPolygon(polygonID int64, pairs ...image.Point) *VirtualPolygon {
 		ID:                polygonID,
 		Coordinates:       make([]image.Point, len(pairs)),
 		SourceCoordinates: make([]image.Point, len(pairs)),
+		visits:            make(map[string]*PolygonVisit),
 	}
 	for i := range pairs {
 		vpolygon.Coordinates[i] = image.Point{X: pairs[i].X, Y: pairs[i].Y}
@@ -57,14 +125,75 @@ func NewVirtualPolygon(polygonID int64, pairs ...image.Point) *VirtualPolygon {
 	} else {
 		vpolygon.PolygonType = CONCAVE_POLYGON
 	}
-	vpolygon.gocvPolyDraw = gocv.NewPointsVectorFromPoints([][]image.Point{vpolygon.Coordinates})
-	vpolygon.gocvPoly = gocv.NewPointVectorFromPoints(vpolygon.Coordinates)
+	vpolygon.updateVectors()
 	return &vpolygon
 }
 
 // Draw Draw virtual polygon on image
+// Number of objects inside of polygon is drawn near its first vertex
 func (vpolygon *VirtualPolygon) Draw(img *gocv.Mat) {
+	vpolygon.geometryMutex.RLock()
+	defer vpolygon.geometryMutex.RUnlock()
 	gocv.Polylines(img, vpolygon.gocvPolyDraw, true, vpolygon.Color, 2)
+	if len(vpolygon.Coordinates) != 0 {
+		gocv.PutText(img, fmt.Sprintf("inside: %d", vpolygon.VisitorsCount()), vpolygon.Coordinates[0], gocv.FontHersheySimplex, 0.5, vpolygon.Color, 1)
+	}
+}
+
+// StartVisit Registers that object has entered polygon at given moment
+// Returns copy of visit. If object is already inside then existing visit is returned
+func (vpolygon *VirtualPolygon) StartVisit(blobID, className string, enteredAt time.Time) PolygonVisit {
+	vpolygon.visitsMutex.Lock()
+	defer vpolygon.visitsMutex.Unlock()
+	if vpolygon.visits == nil {
+		vpolygon.visits = make(map[string]*PolygonVisit)
+	}
+	if visit, ok := vpolygon.visits[blobID]; ok {
+		return *visit
+	}
+	visit := &PolygonVisit{
+		BlobID:    blobID,
+		ClassName: className,
+		EnteredAt: enteredAt,
+	}
+	vpolygon.visits[blobID] = visit
+	return *visit
+}
+
+// FinishVisit Registers that object has left polygon (or has been lost by tracker) at given moment
+// Returns copy of finished visit. Second returned value is false if object has not been inside
+func (vpolygon *VirtualPolygon) FinishVisit(blobID string, leftAt time.Time) (PolygonVisit, bool) {
+	vpolygon.visitsMutex.Lock()
+	defer vpolygon.visitsMutex.Unlock()
+	visit, ok := vpolygon.visits[blobID]
+	if !ok {
+		return PolygonVisit{}, false
+	}
+	delete(vpolygon.visits, blobID)
+	visit.LeftAt = leftAt
+	return *visit, true
+}
+
+// Visitors Returns copies of visits for objects which are inside of polygon at the moment (sorted by time of entering)
+// Use PolygonVisit.Dwell() to get time spent in polygon so far
+func (vpolygon *VirtualPolygon) Visitors() []PolygonVisit {
+	vpolygon.visitsMutex.Lock()
+	visitors := make([]PolygonVisit, 0, len(vpolygon.visits))
+	for _, visit := range vpolygon.visits {
+		visitors = append(visitors, *visit)
+	}
+	vpolygon.visitsMutex.Unlock()
+	sort.Slice(visitors, func(i, j int) bool {
+		return visitors[i].EnteredAt.Before(visitors[j].EnteredAt)
+	})
+	return visitors
+}
+
+// VisitorsCount Returns number of objects which are inside of polygon at the moment
+func (vpolygon *VirtualPolygon) VisitorsCount() int {
+	vpolygon.visitsMutex.Lock()
+	defer vpolygon.visitsMutex.Unlock()
+	return len(vpolygon.visits)
 }
 
 // isConvex check if polygon either convex or concave
@@ -106,82 +235,263 @@ func crossProduct(a image.Point, b image.Point, c image.Point) int {
 }
 
 // Scale Scales down (so scale factor can be > 1.0 ) virtual polygon
-// (scaleX, scaleY) - How to scale source (x1,y1) and (x2,y2) coordinates
+// (scaleX, scaleY) - How to scale source coordinates
 // Important notice:
 // 1. Source coordinates won't be modified
-// 2. Source coordinates would be used for scaling. So you can't scale polygon multiple times
+// 2. Source coordinates would be used for scaling. So polygon could be scaled multiple times (e.g. when resolution of input has been changed) and result depends on the last call only
+// 3. It is safe to call it concurrently with containment checks and drawing: they wait until scaling is done
 func (vpolygon *VirtualPolygon) Scale(scaleX, scaleY float64) {
-	for i := range vpolygon.Coordinates {
-		vpolygon.Coordinates[i].X = int(math.Round(float64(vpolygon.Coordinates[i].X) / scaleX))
-		vpolygon.Coordinates[i].Y = int(math.Round(float64(vpolygon.Coordinates[i].Y) / scaleY))
+	coordinates := make([]image.Point, len(vpolygon.SourceCoordinates))
+	for i := range vpolygon.SourceCoordinates {
+		coordinates[i].X = int(math.Round(float64(vpolygon.SourceCoordinates[i].X) / scaleX))
+		coordinates[i].Y = int(math.Round(float64(vpolygon.SourceCoordinates[i].Y) / scaleY))
 	}
+	vpolygon.geometryMutex.Lock()
+	defer vpolygon.geometryMutex.Unlock()
+	vpolygon.Coordinates = coordinates
+	vpolygon.updateVectors()
+}
+
+// updateVectors Rebuilds native vectors for current (scaled) coordinates. Previous vectors are freed
+// Notice: should be called under geometryMutex (or before polygon is shared)
+func (vpolygon *VirtualPolygon) updateVectors() {
+	vpolygon.closeVectors()
 	vpolygon.gocvPolyDraw = gocv.NewPointsVectorFromPoints([][]image.Point{vpolygon.Coordinates})
 	vpolygon.gocvPoly = gocv.NewPointVectorFromPoints(vpolygon.Coordinates)
 }
 
+// closeVectors Frees native vectors. Zero values are left, so it is safe to call it multiple times
+func (vpolygon *VirtualPolygon) closeVectors() {
+	vpolygon.gocvPoly.Close()
+	vpolygon.gocvPoly = gocv.PointVector{}
+	vpolygon.gocvPolyDraw.Close()
+	vpolygon.gocvPolyDraw = gocv.PointsVector{}
+}
+
+// Close Frees native memory of polygon. It is safe to call it multiple times
+// Notice: polygon can't be used for containment checks and drawing after closing until Scale() is called
+func (vpolygon *VirtualPolygon) Close() {
+	vpolygon.geometryMutex.Lock()
+	defer vpolygon.geometryMutex.Unlock()
+	vpolygon.closeVectors()
+}
+
 // BlobEntered Checks if an object has entered the polygon
-// Let's clarify for future questions: we are assuming the object is represented by a center, not a bounding box
-// So object has entered polygon when its center had entered polygon too
+// Object is represented by anchor of its bounding box (see ref. POLYGON_ANCHOR)
+// So object has entered polygon when it was not inside of polygon on previous position and it is inside on the last one
+// Object which has been detected inside of polygon for the first time is considered as entered also
+// Membership of object is stored in blob's properties (see ref. BlobPolygonsIDs()), so object could be inside of several polygons at once
 func (vpolygon *VirtualPolygon) BlobEntered(b blob.Blobie) bool {
-	// Early exit if blob already have same property
-	if prop, ok := b.GetProperty("polygon_id"); ok {
-		switch propStr := prop.(type) {
-		case int64:
-			if propStr == vpolygon.ID {
-				return false
-			}
-		default:
-			return false
-		}
+	polygons := blobPolygons(b)
+	// Early exit if blob is inside of polygon already
+	if _, ok := polygons[vpolygon.ID]; ok {
+		return false
 	}
 	track := b.GetTrack()
 	n := len(track)
-	if n < 2 {
-		// Blob can't have one coordinates pair in track
+	if n == 0 {
 		return false
 	}
-	lastPosition := track[len(track)-1]
-	secondLastPosition := track[len(track)-2]
-	// If P(xN-1,yN-1) is not inside of polygon and P(xN,yN) is inside of polygon then object has entered the polygon
-	if !vpolygon.ContainsPoint(secondLastPosition) && vpolygon.ContainsPoint(lastPosition) {
-		b.SetProperty("polygon_id", vpolygon.ID)
+	rect := b.GetCurrentRect()
+	lastPosition := track[n-1]
+	if !vpolygon.containsAt(rect, lastPosition, lastPosition) {
+		return false
+	}
+	// If object is not inside of polygon on position N-1 and it is inside on position N then object has entered the polygon
+	if n == 1 || !vpolygon.containsAt(rect, lastPosition, track[n-2]) {
+		polygons[vpolygon.ID] = struct{}{}
 		return true
 	}
 	return false
 }
 
 // BlobLeft Checks if an object has left the polygon
-// Let's clarify for future questions: we are assuming the object is represented by a center, not a bounding box
-// So object has left polygon when its center had left polygon too
+// Object is represented by anchor of its bounding box (see ref. POLYGON_ANCHOR)
+// So object has left polygon when it has been registered as entered by BlobEntered() and it is not inside on the last position
+// Membership is cleared, so leaving is reported only once even if track is not changed (e.g. object has not been matched on next frames)
 func (vpolygon *VirtualPolygon) BlobLeft(b blob.Blobie) bool {
+	polygons := blobPolygons(b)
+	// Early exit if blob has not been inside of polygon
+	if _, ok := polygons[vpolygon.ID]; !ok {
+		return false
+	}
 	track := b.GetTrack()
 	n := len(track)
-	if n < 2 {
-		// Blob can't have one coordinates pair in track
+	if n == 0 {
 		return false
 	}
-	lastPosition := track[len(track)-1]
-	secondLastPosition := track[len(track)-2]
-	// If P(xN-1,yN-1) is inside of polygon and P(xN,yN) is not inside of polygon then object has left the polygon
-	if vpolygon.ContainsPoint(secondLastPosition) && !vpolygon.ContainsPoint(lastPosition) {
-		b.SetProperty("polygon_id", -1)
-		return true
+	rect := b.GetCurrentRect()
+	lastPosition := track[n-1]
+	if vpolygon.containsAt(rect, lastPosition, lastPosition) {
+		return false
 	}
-	return false
+	delete(polygons, vpolygon.ID)
+	return true
+}
+
+// containsAt Checks if polygon contains object which has been located at given point of its track
+// Bounding box of object at that moment is evaluated by shifting current bounding box along the track
+//
+// rect - current bounding box of object
+// lastPosition - last point of track
+// position - point of track to check
+//
+func (vpolygon *VirtualPolygon) containsAt(rect image.Rectangle, lastPosition, position image.Point) bool {
+	if vpolygon.Anchor == POLYGON_ANCHOR_CENTER || vpolygon.Anchor == 0 {
+		return vpolygon.ContainsPoint(position)
+	}
+	return vpolygon.ContainsRect(rect.Add(position.Sub(lastPosition)))
+}
+
+const (
+	// Key of blob's property which contains set of polygons' identifiers
+	blobPolygonsProperty = "polygons"
+)
+
+// blobPolygons Returns set of identifiers of polygons which contain the object
+// Set is created (and stored in blob's properties) if it is absent
+func blobPolygons(b blob.Blobie) map[int64]struct{} {
+	if prop, ok := b.GetProperty(blobPolygonsProperty); ok {
+		if polygons, ok := prop.(map[int64]struct{}); ok {
+			return polygons
+		}
+	}
+	polygons := make(map[int64]struct{})
+	b.SetProperty(blobPolygonsProperty, polygons)
+	return polygons
+}
+
+// BlobPolygonsIDs Returns sorted identifiers of polygons which object is inside of (according to BlobEntered() and BlobLeft() calls)
+func BlobPolygonsIDs(b blob.Blobie) []int64 {
+	polygons := blobPolygons(b)
+	ids := make([]int64, 0, len(polygons))
+	for id := range polygons {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
 }
 
 // ContainsBlob Checks if polygon contains the given object
-// Let's clarify for future questions: we are assuming the object is represented by a center, not a bounding box
-// So object is inside of polygon when its center is inside of polygon too
+// Object is represented by anchor of its bounding box (see ref. POLYGON_ANCHOR)
 func (vpolygon *VirtualPolygon) ContainsBlob(b blob.Blobie) bool {
-	return vpolygon.ContainsPoint(b.GetCenter())
+	if vpolygon.Anchor == POLYGON_ANCHOR_CENTER || vpolygon.Anchor == 0 {
+		return vpolygon.ContainsPoint(b.GetCenter())
+	}
+	return vpolygon.ContainsRect(b.GetCurrentRect())
+}
+
+// ContainsRect Checks if polygon contains the given bounding box according to polygon's anchor
+func (vpolygon *VirtualPolygon) ContainsRect(rect image.Rectangle) bool {
+	switch vpolygon.Anchor {
+	case POLYGON_ANCHOR_BOTTOM_CENTER:
+		return vpolygon.ContainsPoint(image.Point{X: (rect.Min.X + rect.Max.X) / 2, Y: rect.Max.Y})
+	case POLYGON_ANCHOR_ANY_CORNER:
+		corners := []image.Point{
+			rect.Min,
+			{X: rect.Max.X, Y: rect.Min.Y},
+			rect.Max,
+			{X: rect.Min.X, Y: rect.Max.Y},
+		}
+		for _, corner := range corners {
+			if vpolygon.ContainsPoint(corner) {
+				return true
+			}
+		}
+		return false
+	case POLYGON_ANCHOR_OVERLAP:
+		return vpolygon.OverlapFraction(rect) >= vpolygon.MinOverlap
+	default:
+		return vpolygon.ContainsPoint(image.Point{X: (rect.Min.X + rect.Max.X) / 2, Y: (rect.Min.Y + rect.Max.Y) / 2})
+	}
+}
+
+// OverlapFraction Returns fraction of bounding box area which is covered by polygon. Value is in [0; 1]
+func (vpolygon *VirtualPolygon) OverlapFraction(rect image.Rectangle) float64 {
+	rectArea := float64(rect.Dx() * rect.Dy())
+	if rectArea <= 0 {
+		return 0
+	}
+	vpolygon.geometryMutex.RLock()
+	intersection := clipPolygonByRect(vpolygon.Coordinates, rect)
+	vpolygon.geometryMutex.RUnlock()
+	return math.Min(polygonArea(intersection)/rectArea, 1.0)
 }
 
 // ContainsPoint Checks if polygon contains the given point
 func (vpolygon *VirtualPolygon) ContainsPoint(p image.Point) bool {
+	vpolygon.geometryMutex.RLock()
+	defer vpolygon.geometryMutex.RUnlock()
 	return gocv.PointPolygonTest(vpolygon.gocvPoly, p, true) >= 0
 }
 
+// clipPolygonByRect Returns part of polygon which is inside of rectangle
+// Sutherland–Hodgman algorithm is used: see ref. https://en.wikipedia.org/wiki/Sutherland%E2%80%93Hodgman_algorithm
+// Rectangle is convex, so it works for concave polygons too (degenerate edges do not change area)
+func clipPolygonByRect(polygon []image.Point, rect image.Rectangle) [][2]float64 {
+	output := make([][2]float64, len(polygon))
+	for i, pt := range polygon {
+		output[i] = [2]float64{float64(pt.X), float64(pt.Y)}
+	}
+	minX, minY := float64(rect.Min.X), float64(rect.Min.Y)
+	maxX, maxY := float64(rect.Max.X), float64(rect.Max.Y)
+	// Each edge of rectangle is described by 'inside' check and intersection with segment
+	edges := []struct {
+		inside    func(p [2]float64) bool
+		intersect func(a, b [2]float64) [2]float64
+	}{
+		{
+			func(p [2]float64) bool { return p[0] >= minX },
+			func(a, b [2]float64) [2]float64 { return [2]float64{minX, a[1] + (b[1]-a[1])*(minX-a[0])/(b[0]-a[0])} },
+		},
+		{
+			func(p [2]float64) bool { return p[0] <= maxX },
+			func(a, b [2]float64) [2]float64 { return [2]float64{maxX, a[1] + (b[1]-a[1])*(maxX-a[0])/(b[0]-a[0])} },
+		},
+		{
+			func(p [2]float64) bool { return p[1] >= minY },
+			func(a, b [2]float64) [2]float64 { return [2]float64{a[0] + (b[0]-a[0])*(minY-a[1])/(b[1]-a[1]), minY} },
+		},
+		{
+			func(p [2]float64) bool { return p[1] <= maxY },
+			func(a, b [2]float64) [2]float64 { return [2]float64{a[0] + (b[0]-a[0])*(maxY-a[1])/(b[1]-a[1]), maxY} },
+		},
+	}
+	for _, edge := range edges {
+		input := output
+		output = make([][2]float64, 0, len(input)+4)
+		for i := range input {
+			current := input[i]
+			previous := input[(i+len(input)-1)%len(input)]
+			if edge.inside(current) {
+				if !edge.inside(previous) {
+					output = append(output, edge.intersect(previous, current))
+				}
+				output = append(output, current)
+			} else if edge.inside(previous) {
+				output = append(output, edge.intersect(previous, current))
+			}
+		}
+		if len(output) == 0 {
+			break
+		}
+	}
+	return output
+}
+
+// polygonArea Returns area of polygon via shoelace formula: see ref. https://en.wikipedia.org/wiki/Shoelace_formula
+func polygonArea(polygon [][2]float64) float64 {
+	area := 0.0
+	n := len(polygon)
+	for i := range polygon {
+		j := (i + 1) % n
+		area += polygon[i][0]*polygon[j][1] - polygon[j][0]*polygon[i][1]
+	}
+	return math.Abs(area) / 2.0
+}
+
 // convexContainsPoint Checks if CONVEX polygon contains the given point
 // Heavily inspired by this: https://github.com/LdDl/gocv-blob/blob/master/v2/blob/line_cross.go#L5
 // @Warning: Should be deprecated
@@ -234,83 +544,3 @@ func (vpolygon *VirtualPolygon) concaveContainsPoint(p image.Point) bool {
 	// @Warning: Should be deprecated, so no todo :P
 	return false
 }
-
-// isOnSegment Checks if point Q lies on segment PR
-// Input: three colinear points Q, Q and R
-// @Warning: Should be deprecated
-func isOnSegment(Px, Py, Qx, Qy, Rx, Ry int) bool {
-	if Qx <= maxInt(Px, Rx) && Qx >= minInt(Px, Rx) && Qy <= maxInt(Py, Ry) && Qy >= minInt(Py, Ry) {
-		return true
-	}
-	return false
-}
-
-// @Warning: Should be deprecated
-type PointsOrientation int
-
-const (
-	// @Warning: Should be deprecated
-	Collinear = iota
-	Clockwise
-	CounterClockwise
-)
-
-// getOrientation Gets orientations of points P -> Q -> R.
-// Possible output values: Collinear / Clockwise or CounterClockwise
-// Input: points P, Q and R in provided order
-// @Warning: Should be deprecated
-func getOrientation(Px, Py, Qx, Qy, Rx, Ry int) PointsOrientation {
-	val := (Qy-Py)*(Rx-Qx) - (Qx-Px)*(Ry-Qy)
-	if val == 0 {
-		return Collinear
-	}
-	if val > 0 {
-		return Clockwise
-	}
-	return CounterClockwise // if it's neither collinear nor clockwise
-}
-
-// isIntersects Checks if segments intersect each other
-// Input:
-// firstPx, firstPy, firstQx, firstQy === first segment
-// secondPx, secondPy, secondQx, secondQy === second segment
-/*
-Notation
-	P1 = (firstPx, firstPy)
-	Q1 = (firstQx, firstQy)
-	P2 = (secondPx, secondPy)
-	Q2 = (secondQx, secondQy)
-*/
-// @Warning: Should be deprecated
-func isIntersects(firstPx, firstPy, firstQx, firstQy, secondPx, secondPy, secondQx, secondQy int) bool {
-	// Find the four orientations needed for general case and special ones
-	o1 := getOrientation(firstPx, firstPy, firstQx, firstQy, secondPx, secondPy)
-	o2 := getOrientation(firstPx, firstPy, firstQx, firstQy, secondQx, secondQy)
-	o3 := getOrientation(secondPx, secondPy, secondQx, secondQy, firstPx, firstPy)
-	o4 := getOrientation(secondPx, secondPy, secondQx, secondQy, firstQx, firstQy)
-
-	// General case
-	if o1 != o2 && o3 != o4 {
-		return true
-	}
-
-	/* Special cases */
-	// P1, Q1, P2 are colinear and P2 lies on segment P1-Q1
-	if o1 == Collinear && isOnSegment(firstPx, firstPy, secondPx, secondPy, firstQx, firstQy) {
-		return true
-	}
-	// P1, Q1 and Q2 are colinear and Q2 lies on segment P1-Q1
-	if o2 == Collinear && isOnSegment(firstPx, firstPy, secondQx, secondQy, firstQx, firstQy) {
-		return true
-	}
-	// P2, Q2 and P1 are colinear and P1 lies on segment P2-Q2
-	if o3 == Collinear && isOnSegment(secondPx, secondPy, firstPx, firstPy, secondQx, secondQy) {
-		return true
-	}
-	// P2, Q2 and Q1 are colinear and Q1 lies on segment P2-Q2
-	if o4 == Collinear && isOnSegment(secondPx, secondPy, firstQx, firstQy, secondQx, secondQy) {
-		return true
-	}
-	// Segments do not intersect
-	return false
-}
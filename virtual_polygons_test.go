@@ -1,8 +1,11 @@
 package odam
 
 import (
+	"fmt"
 	"image"
+	"math"
 	"testing"
+	"time"
 
 	blob "github.com/LdDl/gocv-blob/v2/blob"
 )
@@ -209,13 +212,7 @@ func TestPolygonBlobEnter(t *testing.T) {
 }
 
 func TestPolygonBlobLeft(t *testing.T) {
-	/* Using same data as in TestPolygonBlobEnter() */
-
-	simpleATime0 := blob.NewSimpleBlobie(image.Rect(30, 2, 43, 13), nil)
-	simpleATime1 := blob.NewSimpleBlobie(image.Rect(28, 8, 41, 18), nil)
-	simpleATime2 := blob.NewSimpleBlobie(image.Rect(29, 17, 43, 26), nil)
-	allblobiesEnter := blob.NewBlobiesDefaults()
-	allblobiesEnter.MatchToExisting([]blob.Blobie{simpleATime0, simpleATime1, simpleATime2})
+	/* Using same data as in TestPolygonBlobEnter(), but objects are tracked frame by frame (as it is done in application) */
 	vpolygon := NewVirtualPolygon(
 		1,
 		image.Point{X: 23, Y: 15},
@@ -223,46 +220,317 @@ func TestPolygonBlobLeft(t *testing.T) {
 		image.Point{X: 67, Y: 41},
 		image.Point{X: 23, Y: 41},
 	)
-	for _, b := range allblobiesEnter.Objects {
-		center := b.GetCenter()
-		if vpolygon.BlobLeft(b) {
-			t.Errorf("Blob with center at [%d, %d] should has NOT been left polygon with coordinates %v", center.X, center.Y, vpolygon.Coordinates)
+	cases := []struct {
+		name  string
+		rects []image.Rectangle
+		left  []bool
+	}{
+		{
+			name:  "enter",
+			rects: []image.Rectangle{image.Rect(30, 2, 43, 13), image.Rect(28, 8, 41, 18), image.Rect(29, 17, 43, 26)},
+			left:  []bool{false, false, false},
+		},
+		{
+			name:  "left",
+			rects: []image.Rectangle{image.Rect(38, 32, 52, 39), image.Rect(40, 35, 53, 42), image.Rect(42, 42, 56, 50)},
+			left:  []bool{false, false, true},
+		},
+		{
+			name:  "inside",
+			rects: []image.Rectangle{image.Rect(49, 16, 64, 23), image.Rect(48, 20, 63, 27), image.Rect(48, 25, 63, 33)},
+			left:  []bool{false, false, false},
+		},
+		{
+			name:  "outside",
+			rects: []image.Rectangle{image.Rect(10, 9, 25, 18), image.Rect(12, 16, 27, 24), image.Rect(11, 21, 27, 30)},
+			left:  []bool{false, false, false},
+		},
+	}
+	for _, c := range cases {
+		allblobies := blob.NewBlobiesDefaults()
+		for i, rect := range c.rects {
+			allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(rect, nil)})
+			for _, b := range allblobies.Objects {
+				left := false
+				if !vpolygon.BlobEntered(b) {
+					left = vpolygon.BlobLeft(b)
+				}
+				if left != c.left[i] {
+					t.Errorf("%s: step #%d leaving polygon with coordinates %v should be %t, but got %t", c.name, i, vpolygon.Coordinates, c.left[i], left)
+				}
+			}
+		}
+		// Track is not changed (e.g. object has not been matched on next frame): leaving should not be reported again
+		for _, b := range allblobies.Objects {
+			if vpolygon.BlobLeft(b) {
+				t.Errorf("%s: leaving polygon with coordinates %v should be reported only once", c.name, vpolygon.Coordinates)
+			}
 		}
 	}
+}
 
-	simpleBTime0 := blob.NewSimpleBlobie(image.Rect(38, 32, 52, 39), nil)
-	simpleBTime1 := blob.NewSimpleBlobie(image.Rect(40, 35, 53, 42), nil)
-	simpleBTime2 := blob.NewSimpleBlobie(image.Rect(42, 42, 56, 50), nil)
-	allblobiesLeft := blob.NewBlobiesDefaults()
-	allblobiesLeft.MatchToExisting([]blob.Blobie{simpleBTime0, simpleBTime1, simpleBTime2})
-	for _, b := range allblobiesLeft.Objects {
-		center := b.GetCenter()
-		if !vpolygon.BlobLeft(b) {
-			t.Errorf("Blob with center at [%d, %d] should has been left polygon with coordinates %v", center.X, center.Y, vpolygon.Coordinates)
+func TestPolygonVisits(t *testing.T) {
+	vpolygon := NewVirtualPolygon(
+		1,
+		image.Point{X: 0, Y: 0},
+		image.Point{X: 5, Y: 0},
+		image.Point{X: 5, Y: 5},
+		image.Point{X: 0, Y: 5},
+	)
+	startTime := time.Date(2021, 8, 30, 12, 0, 0, 0, time.UTC)
+	vpolygon.StartVisit("car-1", "car", startTime)
+	vpolygon.StartVisit("bus-1", "bus", startTime.Add(-10*time.Second))
+	// Repeated entering should not reset time of entering
+	visit := vpolygon.StartVisit("car-1", "car", startTime.Add(5*time.Second))
+	if !visit.EnteredAt.Equal(startTime) {
+		t.Errorf("Object 'car-1' should have entered at %v, but got %v", startTime, visit.EnteredAt)
+	}
+	if vpolygon.VisitorsCount() != 2 {
+		t.Errorf("There should be 2 objects inside, but got %d", vpolygon.VisitorsCount())
+	}
+	now := startTime.Add(20 * time.Second)
+	visitors := vpolygon.Visitors()
+	if len(visitors) != 2 {
+		t.Errorf("There should be 2 visitors, but got %d", len(visitors))
+		return
+	}
+	correctVisitors := []struct {
+		blobID string
+		dwell  time.Duration
+	}{
+		{"bus-1", 30 * time.Second},
+		{"car-1", 20 * time.Second},
+	}
+	for i, correct := range correctVisitors {
+		if visitors[i].BlobID != correct.blobID {
+			t.Errorf("Visitor #%d should be '%s', but got '%s'", i, correct.blobID, visitors[i].BlobID)
 		}
+		if visitors[i].Dwell(now) != correct.dwell {
+			t.Errorf("Visitor '%s' should have dwell %v so far, but got %v", visitors[i].BlobID, correct.dwell, visitors[i].Dwell(now))
+		}
+	}
+	finished, ok := vpolygon.FinishVisit("car-1", startTime.Add(45*time.Second))
+	if !ok {
+		t.Errorf("Object 'car-1' should have been inside")
+	}
+	if finished.Dwell(now) != 45*time.Second {
+		t.Errorf("Object 'car-1' should have dwell %v, but got %v", 45*time.Second, finished.Dwell(now))
+	}
+	if _, ok := vpolygon.FinishVisit("car-1", now); ok {
+		t.Errorf("Object 'car-1' should not be inside after leaving")
+	}
+	if vpolygon.VisitorsCount() != 1 {
+		t.Errorf("There should be 1 object inside, but got %d", vpolygon.VisitorsCount())
 	}
+}
 
-	simpleCTime0 := blob.NewSimpleBlobie(image.Rect(49, 16, 64, 23), nil)
-	simpleCTime1 := blob.NewSimpleBlobie(image.Rect(48, 20, 63, 27), nil)
-	simpleCTime2 := blob.NewSimpleBlobie(image.Rect(48, 25, 63, 33), nil)
-	allblobiesInside := blob.NewBlobiesDefaults()
-	allblobiesInside.MatchToExisting([]blob.Blobie{simpleCTime0, simpleCTime1, simpleCTime2})
-	for _, b := range allblobiesInside.Objects {
-		center := b.GetCenter()
-		if vpolygon.BlobLeft(b) {
-			t.Errorf("Blob with center at [%d, %d] should has NOT been left polygon with coordinates %v", center.X, center.Y, vpolygon.Coordinates)
+func TestPolygonsOverlapping(t *testing.T) {
+	vpolygons := []*VirtualPolygon{
+		// Outer polygon
+		NewVirtualPolygon(
+			1,
+			image.Point{X: 0, Y: 0},
+			image.Point{X: 100, Y: 0},
+			image.Point{X: 100, Y: 100},
+			image.Point{X: 0, Y: 100},
+		),
+		// Nested into first one
+		NewVirtualPolygon(
+			2,
+			image.Point{X: 40, Y: 40},
+			image.Point{X: 80, Y: 40},
+			image.Point{X: 80, Y: 80},
+			image.Point{X: 40, Y: 80},
+		),
+		// Overlaps first and second ones
+		NewVirtualPolygon(
+			3,
+			image.Point{X: 60, Y: 0},
+			image.Point{X: 140, Y: 0},
+			image.Point{X: 140, Y: 100},
+			image.Point{X: 60, Y: 100},
+		),
+	}
+	// Object moves from left to right through all polygons
+	allblobies := blob.NewBlobiesDefaults()
+	events := []string{}
+	var idsInMiddle []int64
+	for x := -10; x <= 150; x += 4 {
+		allblobies.MatchToExisting([]blob.Blobie{blob.NewSimpleBlobie(image.Rect(x-10, 50, x+10, 70), nil)})
+		if len(allblobies.Objects) != 1 {
+			t.Errorf("There should be exactly one tracked object, but got %d", len(allblobies.Objects))
+			return
+		}
+		for _, b := range allblobies.Objects {
+			for _, vpolygon := range vpolygons {
+				if vpolygon.BlobEntered(b) {
+					events = append(events, fmt.Sprintf("entered %d", vpolygon.ID))
+				}
+				if vpolygon.BlobLeft(b) {
+					events = append(events, fmt.Sprintf("left %d", vpolygon.ID))
+				}
+			}
+			if x == 70 {
+				idsInMiddle = BlobPolygonsIDs(b)
+			}
 		}
 	}
+	correctEvents := []string{"entered 1", "entered 2", "entered 3", "left 2", "left 1", "left 3"}
+	if len(events) != len(correctEvents) {
+		t.Errorf("Events should be %v, but got %v", correctEvents, events)
+	} else {
+		for i := range correctEvents {
+			if events[i] != correctEvents[i] {
+				t.Errorf("Event #%d should be '%s', but got '%s'", i, correctEvents[i], events[i])
+			}
+		}
+	}
+	correctIDs := []int64{1, 2, 3}
+	if len(idsInMiddle) != len(correctIDs) {
+		t.Errorf("Object should be inside of polygons %v, but got %v", correctIDs, idsInMiddle)
+	} else {
+		for i := range correctIDs {
+			if idsInMiddle[i] != correctIDs[i] {
+				t.Errorf("Object should be inside of polygons %v, but got %v", correctIDs, idsInMiddle)
+				break
+			}
+		}
+	}
+}
 
-	simpleDTime0 := blob.NewSimpleBlobie(image.Rect(10, 9, 25, 18), nil)
-	simpleDTime1 := blob.NewSimpleBlobie(image.Rect(12, 16, 27, 24), nil)
-	simpleDTime2 := blob.NewSimpleBlobie(image.Rect(11, 21, 27, 30), nil)
-	allblobiesOutside := blob.NewBlobiesDefaults()
-	allblobiesOutside.MatchToExisting([]blob.Blobie{simpleDTime0, simpleDTime1, simpleDTime2})
-	for _, b := range allblobiesOutside.Objects {
-		center := b.GetCenter()
-		if vpolygon.BlobLeft(b) {
-			t.Errorf("Blob with center at [%d, %d] should has NOT been left polygon with coordinates %v", center.X, center.Y, vpolygon.Coordinates)
+func TestPolygonOverlapFraction(t *testing.T) {
+	square := NewVirtualPolygon(
+		1,
+		image.Point{X: 0, Y: 0},
+		image.Point{X: 10, Y: 0},
+		image.Point{X: 10, Y: 10},
+		image.Point{X: 0, Y: 10},
+	)
+	triangle := NewVirtualPolygon(
+		2,
+		image.Point{X: 0, Y: 0},
+		image.Point{X: 10, Y: 0},
+		image.Point{X: 0, Y: 10},
+	)
+	// U-shaped polygon: notch is [4; 6] by X and [0; 6] by Y
+	concave := NewVirtualPolygon(
+		3,
+		image.Point{X: 0, Y: 0},
+		image.Point{X: 4, Y: 0},
+		image.Point{X: 4, Y: 6},
+		image.Point{X: 6, Y: 6},
+		image.Point{X: 6, Y: 0},
+		image.Point{X: 10, Y: 0},
+		image.Point{X: 10, Y: 10},
+		image.Point{X: 0, Y: 10},
+	)
+	cases := []struct {
+		vpolygon *VirtualPolygon
+		rect     image.Rectangle
+		fraction float64
+	}{
+		{square, image.Rect(2, 2, 8, 8), 1.0},
+		{square, image.Rect(5, 0, 15, 10), 0.5},
+		{square, image.Rect(8, 8, 12, 12), 0.25},
+		{square, image.Rect(20, 20, 30, 30), 0.0},
+		{triangle, image.Rect(0, 0, 10, 10), 0.5},
+		{concave, image.Rect(0, 0, 10, 10), 0.88},
+		{concave, image.Rect(4, 0, 6, 6), 0.0},
+	}
+	for _, c := range cases {
+		fraction := c.vpolygon.OverlapFraction(c.rect)
+		if math.Abs(fraction-c.fraction) > 1e-9 {
+			t.Errorf("Polygon %v should cover %f of rectangle %v, but got %f", c.vpolygon.Coordinates, c.fraction, c.rect, fraction)
+		}
+	}
+}
+
+func TestPolygonContainsRect(t *testing.T) {
+	vpolygon := NewVirtualPolygon(
+		1,
+		image.Point{X: 0, Y: 50},
+		image.Point{X: 100, Y: 50},
+		image.Point{X: 100, Y: 100},
+		image.Point{X: 0, Y: 100},
+	)
+	// Large truck: its center is outside of polygon, but its bottom part is inside
+	truckRect := image.Rect(20, 10, 60, 70)
+	cases := []struct {
+		anchor     POLYGON_ANCHOR
+		minOverlap float64
+		contains   bool
+	}{
+		{POLYGON_ANCHOR_CENTER, 0, false},
+		{POLYGON_ANCHOR_BOTTOM_CENTER, 0, true},
+		{POLYGON_ANCHOR_ANY_CORNER, 0, true},
+		{POLYGON_ANCHOR_OVERLAP, 0.3, true},
+		{POLYGON_ANCHOR_OVERLAP, 0.5, false},
+	}
+	for _, c := range cases {
+		vpolygon.Anchor = c.anchor
+		vpolygon.MinOverlap = c.minOverlap
+		if vpolygon.ContainsRect(truckRect) != c.contains {
+			t.Errorf("Polygon with anchor '%s' (min overlap %f): containment of rectangle %v should be %t", c.anchor, c.minOverlap, truckRect, c.contains)
+		}
+	}
+}
+
+func TestPolygonScale(t *testing.T) {
+	vpolygon := NewVirtualPolygon(
+		1,
+		image.Point{X: 100, Y: 100},
+		image.Point{X: 300, Y: 100},
+		image.Point{X: 300, Y: 300},
+	)
+	defer vpolygon.Close()
+	scales := []struct {
+		scaleX      float64
+		scaleY      float64
+		coordinates []image.Point
+	}{
+		{2.0, 2.0, []image.Point{{X: 50, Y: 50}, {X: 150, Y: 50}, {X: 150, Y: 150}}},
+		// Repeated scaling should give same result
+		{2.0, 2.0, []image.Point{{X: 50, Y: 50}, {X: 150, Y: 50}, {X: 150, Y: 150}}},
+		{4.0, 2.0, []image.Point{{X: 25, Y: 50}, {X: 75, Y: 50}, {X: 75, Y: 150}}},
+		{1.0, 1.0, []image.Point{{X: 100, Y: 100}, {X: 300, Y: 100}, {X: 300, Y: 300}}},
+	}
+	for _, scale := range scales {
+		vpolygon.Scale(scale.scaleX, scale.scaleY)
+		for i := range scale.coordinates {
+			if vpolygon.Coordinates[i] != scale.coordinates[i] {
+				t.Errorf("Scale (%f, %f): point #%d should be %v, but got %v", scale.scaleX, scale.scaleY, i, scale.coordinates[i], vpolygon.Coordinates[i])
+			}
+		}
+	}
+	if vpolygon.SourceCoordinates[1] != (image.Point{X: 300, Y: 100}) {
+		t.Errorf("Source coordinates should not be modified, but got %v", vpolygon.SourceCoordinates)
+	}
+	// Closing multiple times should be safe
+	vpolygon.Close()
+}
+
+func TestPolygonScaleConcurrent(t *testing.T) {
+	vpolygon := NewVirtualPolygon(
+		1,
+		image.Point{X: 100, Y: 100},
+		image.Point{X: 300, Y: 100},
+		image.Point{X: 300, Y: 300},
+		image.Point{X: 100, Y: 300},
+	)
+	defer vpolygon.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			vpolygon.Scale(float64(1+i%2), float64(1+i%2))
+		}
+	}()
+	// Point is inside of polygon for both scales, so rescaling should never break containment checks
+	for i := 0; i < 100; i++ {
+		if !vpolygon.ContainsPoint(image.Point{X: 120, Y: 120}) {
+			t.Errorf("Point should be inside of polygon while it is being rescaled")
+			break
 		}
 	}
+	<-done
 }
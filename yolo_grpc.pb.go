@@ -24,6 +24,114 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Direction of crossing the virtual line
+type LineDirection int32
+
+const (
+	LineDirection_LINE_DIRECTION_UNDEFINED LineDirection = 0
+	// Object is moving to the detector (camera)
+	LineDirection_LINE_DIRECTION_TO_DETECTOR LineDirection = 1
+	// Object is moving from the detector (camera)
+	LineDirection_LINE_DIRECTION_FROM_DETECTOR LineDirection = 2
+)
+
+// Enum value maps for LineDirection.
+var (
+	LineDirection_name = map[int32]string{
+		0: "LINE_DIRECTION_UNDEFINED",
+		1: "LINE_DIRECTION_TO_DETECTOR",
+		2: "LINE_DIRECTION_FROM_DETECTOR",
+	}
+	LineDirection_value = map[string]int32{
+		"LINE_DIRECTION_UNDEFINED":     0,
+		"LINE_DIRECTION_TO_DETECTOR":   1,
+		"LINE_DIRECTION_FROM_DETECTOR": 2,
+	}
+)
+
+func (x LineDirection) Enum() *LineDirection {
+	p := new(LineDirection)
+	*p = x
+	return p
+}
+
+func (x LineDirection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LineDirection) Descriptor() protoreflect.EnumDescriptor {
+	return file_yolo_grpc_proto_enumTypes[0].Descriptor()
+}
+
+func (LineDirection) Type() protoreflect.EnumType {
+	return &file_yolo_grpc_proto_enumTypes[0]
+}
+
+func (x LineDirection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LineDirection.Descriptor instead.
+func (LineDirection) EnumDescriptor() ([]byte, []int) {
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{0}
+}
+
+// Type of event for virtual polygon
+type PolygonEventType int32
+
+const (
+	PolygonEventType_POLYGON_EVENT_UNDEFINED PolygonEventType = 0
+	// Object has entered polygon
+	PolygonEventType_POLYGON_EVENT_ENTERED PolygonEventType = 1
+	// Object has left polygon
+	PolygonEventType_POLYGON_EVENT_LEFT PolygonEventType = 2
+	// Object has been lost by tracker while it was inside of polygon
+	PolygonEventType_POLYGON_EVENT_TRACK_LOST PolygonEventType = 3
+)
+
+// Enum value maps for PolygonEventType.
+var (
+	PolygonEventType_name = map[int32]string{
+		0: "POLYGON_EVENT_UNDEFINED",
+		1: "POLYGON_EVENT_ENTERED",
+		2: "POLYGON_EVENT_LEFT",
+		3: "POLYGON_EVENT_TRACK_LOST",
+	}
+	PolygonEventType_value = map[string]int32{
+		"POLYGON_EVENT_UNDEFINED":  0,
+		"POLYGON_EVENT_ENTERED":    1,
+		"POLYGON_EVENT_LEFT":       2,
+		"POLYGON_EVENT_TRACK_LOST": 3,
+	}
+)
+
+func (x PolygonEventType) Enum() *PolygonEventType {
+	p := new(PolygonEventType)
+	*p = x
+	return p
+}
+
+func (x PolygonEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PolygonEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_yolo_grpc_proto_enumTypes[1].Descriptor()
+}
+
+func (PolygonEventType) Type() protoreflect.EnumType {
+	return &file_yolo_grpc_proto_enumTypes[1]
+}
+
+func (x PolygonEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PolygonEventType.Descriptor instead.
+func (PolygonEventType) EnumDescriptor() ([]byte, []int) {
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{1}
+}
+
 // Reference info about detection, camera, timestamp and etc.
 type ObjectInformation struct {
 	state         protoimpl.MessageState
@@ -44,6 +152,10 @@ type ObjectInformation struct {
 	VirtualLine *VirtualLineInfo `protobuf:"bytes,6,opt,name=virtual_line,json=virtualLine,proto3" json:"virtual_line,omitempty"`
 	// Reference information about tracking parameters of object (speed + track points)
 	TrackInformation *TrackInfo `protobuf:"bytes,7,opt,name=track_information,json=trackInformation,proto3" json:"track_information,omitempty"`
+	// Reference information about virtual polygon (when object has entered or left it)
+	VirtualPolygon *VirtualPolygonInfo `protobuf:"bytes,8,opt,name=virtual_polygon,json=virtualPolygon,proto3" json:"virtual_polygon,omitempty"`
+	// Reference information about speed limit violation. Field 'image' contains crop of object then
+	SpeedViolation *SpeedViolationInfo `protobuf:"bytes,9,opt,name=speed_violation,json=speedViolation,proto3" json:"speed_violation,omitempty"`
 }
 
 func (x *ObjectInformation) Reset() {
@@ -127,6 +239,20 @@ func (x *ObjectInformation) GetTrackInformation() *TrackInfo {
 	return nil
 }
 
+func (x *ObjectInformation) GetVirtualPolygon() *VirtualPolygonInfo {
+	if x != nil {
+		return x.VirtualPolygon
+	}
+	return nil
+}
+
+func (x *ObjectInformation) GetSpeedViolation() *SpeedViolationInfo {
+	if x != nil {
+		return x.SpeedViolation
+	}
+	return nil
+}
+
 // Reference information about detection rectangle
 type Detection struct {
 	state         protoimpl.MessageState
@@ -266,6 +392,10 @@ type VirtualLineInfo struct {
 	LeftY  int32 `protobuf:"varint,3,opt,name=left_y,json=leftY,proto3" json:"left_y,omitempty"`
 	RightX int32 `protobuf:"varint,4,opt,name=right_x,json=rightX,proto3" json:"right_x,omitempty"`
 	RightY int32 `protobuf:"varint,5,opt,name=right_y,json=rightY,proto3" json:"right_y,omitempty"`
+	// Direction in which object has crossed the line
+	Direction LineDirection `protobuf:"varint,6,opt,name=direction,proto3,enum=odam.LineDirection" json:"direction,omitempty"`
+	// Vertices of polyline (first and last ones are the same as left and right points)
+	Points []*EuclideanPoint `protobuf:"bytes,7,rep,name=points,proto3" json:"points,omitempty"`
 }
 
 func (x *VirtualLineInfo) Reset() {
@@ -335,6 +465,246 @@ func (x *VirtualLineInfo) GetRightY() int32 {
 	return 0
 }
 
+func (x *VirtualLineInfo) GetDirection() LineDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return LineDirection_LINE_DIRECTION_UNDEFINED
+}
+
+func (x *VirtualLineInfo) GetPoints() []*EuclideanPoint {
+	if x != nil {
+		return x.Points
+	}
+	return nil
+}
+
+// Reference information about virtual polygon
+type VirtualPolygonInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Coordinates of polygon's vertices
+	Coordinates []*EuclideanPoint `protobuf:"bytes,2,rep,name=coordinates,proto3" json:"coordinates,omitempty"`
+	// What has happened to object
+	EventType PolygonEventType `protobuf:"varint,3,opt,name=event_type,json=eventType,proto3,enum=odam.PolygonEventType" json:"event_type,omitempty"`
+	// When object has entered polygon (Unix UTC)
+	EnteredAt int64 `protobuf:"varint,4,opt,name=entered_at,json=enteredAt,proto3" json:"entered_at,omitempty"`
+	// Time spent in polygon (for POLYGON_EVENT_LEFT and POLYGON_EVENT_TRACK_LOST events)
+	DwellSeconds float32 `protobuf:"fixed32,5,opt,name=dwell_seconds,json=dwellSeconds,proto3" json:"dwell_seconds,omitempty"`
+}
+
+func (x *VirtualPolygonInfo) Reset() {
+	*x = VirtualPolygonInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yolo_grpc_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VirtualPolygonInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VirtualPolygonInfo) ProtoMessage() {}
+
+func (x *VirtualPolygonInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_yolo_grpc_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VirtualPolygonInfo.ProtoReflect.Descriptor instead.
+func (*VirtualPolygonInfo) Descriptor() ([]byte, []int) {
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VirtualPolygonInfo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *VirtualPolygonInfo) GetCoordinates() []*EuclideanPoint {
+	if x != nil {
+		return x.Coordinates
+	}
+	return nil
+}
+
+func (x *VirtualPolygonInfo) GetEventType() PolygonEventType {
+	if x != nil {
+		return x.EventType
+	}
+	return PolygonEventType_POLYGON_EVENT_UNDEFINED
+}
+
+func (x *VirtualPolygonInfo) GetEnteredAt() int64 {
+	if x != nil {
+		return x.EnteredAt
+	}
+	return 0
+}
+
+func (x *VirtualPolygonInfo) GetDwellSeconds() float32 {
+	if x != nil {
+		return x.DwellSeconds
+	}
+	return 0
+}
+
+// Reference information about speed limit violation
+type SpeedViolationInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Speed limit which has been exceeded
+	SpeedLimit float32 `protobuf:"fixed32,1,opt,name=speed_limit,json=speedLimit,proto3" json:"speed_limit,omitempty"`
+	// Is limit defined for polygon (zone)? Otherwise it is defined for class of object
+	ZoneLimit bool `protobuf:"varint,2,opt,name=zone_limit,json=zoneLimit,proto3" json:"zone_limit,omitempty"`
+	// Identifier of polygon (for zone limit only)
+	PolygonId int64 `protobuf:"varint,3,opt,name=polygon_id,json=polygonId,proto3" json:"polygon_id,omitempty"`
+	// Speed estimations which have triggered violation
+	Samples []*SpeedSample `protobuf:"bytes,4,rep,name=samples,proto3" json:"samples,omitempty"`
+	// Bytes representation of full-resolution frame (JPEG)
+	FullImage []byte `protobuf:"bytes,5,opt,name=full_image,json=fullImage,proto3" json:"full_image,omitempty"`
+}
+
+func (x *SpeedViolationInfo) Reset() {
+	*x = SpeedViolationInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yolo_grpc_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpeedViolationInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpeedViolationInfo) ProtoMessage() {}
+
+func (x *SpeedViolationInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_yolo_grpc_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpeedViolationInfo.ProtoReflect.Descriptor instead.
+func (*SpeedViolationInfo) Descriptor() ([]byte, []int) {
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SpeedViolationInfo) GetSpeedLimit() float32 {
+	if x != nil {
+		return x.SpeedLimit
+	}
+	return 0
+}
+
+func (x *SpeedViolationInfo) GetZoneLimit() bool {
+	if x != nil {
+		return x.ZoneLimit
+	}
+	return false
+}
+
+func (x *SpeedViolationInfo) GetPolygonId() int64 {
+	if x != nil {
+		return x.PolygonId
+	}
+	return 0
+}
+
+func (x *SpeedViolationInfo) GetSamples() []*SpeedSample {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+func (x *SpeedViolationInfo) GetFullImage() []byte {
+	if x != nil {
+		return x.FullImage
+	}
+	return nil
+}
+
+// Single estimation of speed
+type SpeedSample struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Timestamp in Unix UTC (milliseconds)
+	Timestamp int64   `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Speed     float32 `protobuf:"fixed32,2,opt,name=speed,proto3" json:"speed,omitempty"`
+}
+
+func (x *SpeedSample) Reset() {
+	*x = SpeedSample{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_yolo_grpc_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpeedSample) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpeedSample) ProtoMessage() {}
+
+func (x *SpeedSample) ProtoReflect() protoreflect.Message {
+	mi := &file_yolo_grpc_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpeedSample.ProtoReflect.Descriptor instead.
+func (*SpeedSample) Descriptor() ([]byte, []int) {
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SpeedSample) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SpeedSample) GetSpeed() float32 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
 // Information about estimated speed and track itself
 type TrackInfo struct {
 	state         protoimpl.MessageState
@@ -348,7 +718,7 @@ type TrackInfo struct {
 func (x *TrackInfo) Reset() {
 	*x = TrackInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_yolo_grpc_proto_msgTypes[4]
+		mi := &file_yolo_grpc_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -361,7 +731,7 @@ func (x *TrackInfo) String() string {
 func (*TrackInfo) ProtoMessage() {}
 
 func (x *TrackInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_yolo_grpc_proto_msgTypes[4]
+	mi := &file_yolo_grpc_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -374,7 +744,7 @@ func (x *TrackInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrackInfo.ProtoReflect.Descriptor instead.
 func (*TrackInfo) Descriptor() ([]byte, []int) {
-	return file_yolo_grpc_proto_rawDescGZIP(), []int{4}
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *TrackInfo) GetEstimatedSpeed() float32 {
@@ -399,12 +769,14 @@ type Point struct {
 
 	EuclideanPoint *EuclideanPoint `protobuf:"bytes,1,opt,name=euclidean_point,json=euclideanPoint,proto3" json:"euclidean_point,omitempty"`
 	Wgs84Point     *WGS84Point     `protobuf:"bytes,2,opt,name=wgs84_point,json=wgs84Point,proto3" json:"wgs84_point,omitempty"`
+	// Local planar coordinates in meters (when 'mapper_type' is 'metric'). 'wgs84_point' is empty then
+	MetricPoint *EuclideanPoint `protobuf:"bytes,3,opt,name=metric_point,json=metricPoint,proto3" json:"metric_point,omitempty"`
 }
 
 func (x *Point) Reset() {
 	*x = Point{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_yolo_grpc_proto_msgTypes[5]
+		mi := &file_yolo_grpc_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -417,7 +789,7 @@ func (x *Point) String() string {
 func (*Point) ProtoMessage() {}
 
 func (x *Point) ProtoReflect() protoreflect.Message {
-	mi := &file_yolo_grpc_proto_msgTypes[5]
+	mi := &file_yolo_grpc_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -430,7 +802,7 @@ func (x *Point) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Point.ProtoReflect.Descriptor instead.
 func (*Point) Descriptor() ([]byte, []int) {
-	return file_yolo_grpc_proto_rawDescGZIP(), []int{5}
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Point) GetEuclideanPoint() *EuclideanPoint {
@@ -447,6 +819,13 @@ func (x *Point) GetWgs84Point() *WGS84Point {
 	return nil
 }
 
+func (x *Point) GetMetricPoint() *EuclideanPoint {
+	if x != nil {
+		return x.MetricPoint
+	}
+	return nil
+}
+
 // Representation of a point in Euclidean space
 type EuclideanPoint struct {
 	state         protoimpl.MessageState
@@ -460,7 +839,7 @@ type EuclideanPoint struct {
 func (x *EuclideanPoint) Reset() {
 	*x = EuclideanPoint{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_yolo_grpc_proto_msgTypes[6]
+		mi := &file_yolo_grpc_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -473,7 +852,7 @@ func (x *EuclideanPoint) String() string {
 func (*EuclideanPoint) ProtoMessage() {}
 
 func (x *EuclideanPoint) ProtoReflect() protoreflect.Message {
-	mi := &file_yolo_grpc_proto_msgTypes[6]
+	mi := &file_yolo_grpc_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -486,7 +865,7 @@ func (x *EuclideanPoint) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EuclideanPoint.ProtoReflect.Descriptor instead.
 func (*EuclideanPoint) Descriptor() ([]byte, []int) {
-	return file_yolo_grpc_proto_rawDescGZIP(), []int{6}
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *EuclideanPoint) GetX() float32 {
@@ -516,7 +895,7 @@ type WGS84Point struct {
 func (x *WGS84Point) Reset() {
 	*x = WGS84Point{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_yolo_grpc_proto_msgTypes[7]
+		mi := &file_yolo_grpc_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -529,7 +908,7 @@ func (x *WGS84Point) String() string {
 func (*WGS84Point) ProtoMessage() {}
 
 func (x *WGS84Point) ProtoReflect() protoreflect.Message {
-	mi := &file_yolo_grpc_proto_msgTypes[7]
+	mi := &file_yolo_grpc_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -542,7 +921,7 @@ func (x *WGS84Point) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WGS84Point.ProtoReflect.Descriptor instead.
 func (*WGS84Point) Descriptor() ([]byte, []int) {
-	return file_yolo_grpc_proto_rawDescGZIP(), []int{7}
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *WGS84Point) GetLongitude() float32 {
@@ -573,7 +952,7 @@ type Response struct {
 func (x *Response) Reset() {
 	*x = Response{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_yolo_grpc_proto_msgTypes[8]
+		mi := &file_yolo_grpc_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -586,7 +965,7 @@ func (x *Response) String() string {
 func (*Response) ProtoMessage() {}
 
 func (x *Response) ProtoReflect() protoreflect.Message {
-	mi := &file_yolo_grpc_proto_msgTypes[8]
+	mi := &file_yolo_grpc_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -599,7 +978,7 @@ func (x *Response) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Response.ProtoReflect.Descriptor instead.
 func (*Response) Descriptor() ([]byte, []int) {
-	return file_yolo_grpc_proto_rawDescGZIP(), []int{8}
+	return file_yolo_grpc_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *Response) GetMessage() string {
@@ -627,7 +1006,7 @@ var File_yolo_grpc_proto protoreflect.FileDescriptor
 
 var file_yolo_grpc_proto_rawDesc = []byte{
 	0x0a, 0x0f, 0x79, 0x6f, 0x6c, 0x6f, 0x5f, 0x67, 0x72, 0x70, 0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x04, 0x6f, 0x64, 0x61, 0x6d, 0x22, 0xac, 0x02, 0x0a, 0x11, 0x4f, 0x62, 0x6a, 0x65,
+	0x6f, 0x12, 0x04, 0x6f, 0x64, 0x61, 0x6d, 0x22, 0xb2, 0x03, 0x0a, 0x11, 0x4f, 0x62, 0x6a, 0x65,
 	0x63, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x15, 0x0a,
 	0x06, 0x63, 0x61, 0x6d, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x63,
 	0x61, 0x6d, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
@@ -646,39 +1025,87 @@ var file_yolo_grpc_proto_rawDesc = []byte{
 	0x6b, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x07, 0x20,
 	0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x6b,
 	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x10, 0x74, 0x72, 0x61, 0x63, 0x6b, 0x49, 0x6e, 0x66, 0x6f, 0x72,
-	0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x65, 0x0a, 0x09, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x12, 0x15, 0x0a, 0x06, 0x78, 0x5f, 0x6c, 0x65, 0x66, 0x74, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x05, 0x52, 0x05, 0x78, 0x4c, 0x65, 0x66, 0x74, 0x12, 0x13, 0x0a, 0x05, 0x79, 0x5f,
-	0x74, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x79, 0x54, 0x6f, 0x70, 0x12,
-	0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x22, 0x45, 0x0a,
-	0x09, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x6c,
-	0x61, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x63, 0x6c,
-	0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x5f, 0x6e,
-	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6c, 0x61, 0x73, 0x73,
-	0x4e, 0x61, 0x6d, 0x65, 0x22, 0x81, 0x01, 0x0a, 0x0f, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c,
-	0x4c, 0x69, 0x6e, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x15, 0x0a, 0x06, 0x6c, 0x65, 0x66, 0x74,
-	0x5f, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x65, 0x66, 0x74, 0x58, 0x12,
-	0x15, 0x0a, 0x06, 0x6c, 0x65, 0x66, 0x74, 0x5f, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x05, 0x6c, 0x65, 0x66, 0x74, 0x59, 0x12, 0x17, 0x0a, 0x07, 0x72, 0x69, 0x67, 0x68, 0x74, 0x5f,
-	0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x69, 0x67, 0x68, 0x74, 0x58, 0x12,
-	0x17, 0x0a, 0x07, 0x72, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05,
-	0x52, 0x06, 0x72, 0x69, 0x67, 0x68, 0x74, 0x59, 0x22, 0x59, 0x0a, 0x09, 0x54, 0x72, 0x61, 0x63,
-	0x6b, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x27, 0x0a, 0x0f, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74,
-	0x65, 0x64, 0x5f, 0x73, 0x70, 0x65, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0e,
-	0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x53, 0x70, 0x65, 0x65, 0x64, 0x12, 0x23,
-	0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b,
-	0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x06, 0x70, 0x6f, 0x69,
-	0x6e, 0x74, 0x73, 0x22, 0x79, 0x0a, 0x05, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x3d, 0x0a, 0x0f,
-	0x65, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x45, 0x75, 0x63,
-	0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x0e, 0x65, 0x75, 0x63,
-	0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x31, 0x0a, 0x0b, 0x77,
-	0x67, 0x73, 0x38, 0x34, 0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x10, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x57, 0x47, 0x53, 0x38, 0x34, 0x50, 0x6f, 0x69,
-	0x6e, 0x74, 0x52, 0x0a, 0x77, 0x67, 0x73, 0x38, 0x34, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0x2c,
+	0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x41, 0x0a, 0x0f, 0x76, 0x69, 0x72, 0x74, 0x75, 0x61,
+	0x6c, 0x5f, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x50, 0x6f,
+	0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0e, 0x76, 0x69, 0x72, 0x74, 0x75,
+	0x61, 0x6c, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x12, 0x41, 0x0a, 0x0f, 0x73, 0x70, 0x65,
+	0x65, 0x64, 0x5f, 0x76, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x53, 0x70, 0x65, 0x65, 0x64, 0x56,
+	0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0e, 0x73, 0x70,
+	0x65, 0x65, 0x64, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x65, 0x0a, 0x09,
+	0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x15, 0x0a, 0x06, 0x78, 0x5f, 0x6c,
+	0x65, 0x66, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x78, 0x4c, 0x65, 0x66, 0x74,
+	0x12, 0x13, 0x0a, 0x05, 0x79, 0x5f, 0x74, 0x6f, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x04, 0x79, 0x54, 0x6f, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x77, 0x69,
+	0x64, 0x74, 0x68, 0x22, 0x45, 0x0a, 0x09, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x19, 0x0a, 0x08, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x63,
+	0x6c, 0x61, 0x73, 0x73, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xe2, 0x01, 0x0a, 0x0f, 0x56,
+	0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x4c, 0x69, 0x6e, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x15,
+	0x0a, 0x06, 0x6c, 0x65, 0x66, 0x74, 0x5f, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x6c, 0x65, 0x66, 0x74, 0x58, 0x12, 0x15, 0x0a, 0x06, 0x6c, 0x65, 0x66, 0x74, 0x5f, 0x79, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x65, 0x66, 0x74, 0x59, 0x12, 0x17, 0x0a, 0x07,
+	0x72, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72,
+	0x69, 0x67, 0x68, 0x74, 0x58, 0x12, 0x17, 0x0a, 0x07, 0x72, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x79,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x72, 0x69, 0x67, 0x68, 0x74, 0x59, 0x12, 0x31,
+	0x0a, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x13, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x4c, 0x69, 0x6e, 0x65, 0x44, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x2c, 0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x14, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x45, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65,
+	0x61, 0x6e, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x22,
+	0xd7, 0x01, 0x0a, 0x12, 0x56, 0x69, 0x72, 0x74, 0x75, 0x61, 0x6c, 0x50, 0x6f, 0x6c, 0x79, 0x67,
+	0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x36, 0x0a, 0x0b, 0x63, 0x6f, 0x6f, 0x72, 0x64, 0x69,
+	0x6e, 0x61, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6f, 0x64,
+	0x61, 0x6d, 0x2e, 0x45, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x50, 0x6f, 0x69, 0x6e,
+	0x74, 0x52, 0x0b, 0x63, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x73, 0x12, 0x35,
+	0x0a, 0x0a, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x16, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f,
+	0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x09, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x65, 0x64,
+	0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x65, 0x6e, 0x74, 0x65, 0x72,
+	0x65, 0x64, 0x41, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x77, 0x65, 0x6c, 0x6c, 0x5f, 0x73, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0c, 0x64, 0x77, 0x65,
+	0x6c, 0x6c, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0xbf, 0x01, 0x0a, 0x12, 0x53, 0x70,
+	0x65, 0x65, 0x64, 0x56, 0x69, 0x6f, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x70, 0x65, 0x65, 0x64, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x73, 0x70, 0x65, 0x65, 0x64, 0x4c, 0x69, 0x6d, 0x69,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x7a, 0x6f, 0x6e, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x7a, 0x6f, 0x6e, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x70, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x49, 0x64, 0x12,
+	0x2b, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x53, 0x70, 0x65, 0x65, 0x64, 0x53, 0x61, 0x6d,
+	0x70, 0x6c, 0x65, 0x52, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a,
+	0x66, 0x75, 0x6c, 0x6c, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x09, 0x66, 0x75, 0x6c, 0x6c, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x22, 0x41, 0x0a, 0x0b, 0x53,
+	0x70, 0x65, 0x65, 0x64, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x70, 0x65, 0x65,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x05, 0x73, 0x70, 0x65, 0x65, 0x64, 0x22, 0x59,
+	0x0a, 0x09, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x27, 0x0a, 0x0f, 0x65,
+	0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x73, 0x70, 0x65, 0x65, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x02, 0x52, 0x0e, 0x65, 0x73, 0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x53,
+	0x70, 0x65, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x02,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x50, 0x6f, 0x69, 0x6e,
+	0x74, 0x52, 0x06, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x22, 0xb2, 0x01, 0x0a, 0x05, 0x50, 0x6f,
+	0x69, 0x6e, 0x74, 0x12, 0x3d, 0x0a, 0x0f, 0x65, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e,
+	0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6f,
+	0x64, 0x61, 0x6d, 0x2e, 0x45, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x52, 0x0e, 0x65, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x12, 0x31, 0x0a, 0x0b, 0x77, 0x67, 0x73, 0x38, 0x34, 0x5f, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x57,
+	0x47, 0x53, 0x38, 0x34, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x0a, 0x77, 0x67, 0x73, 0x38, 0x34,
+	0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x37, 0x0a, 0x0c, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x5f,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6f, 0x64,
+	0x61, 0x6d, 0x2e, 0x45, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x50, 0x6f, 0x69, 0x6e,
+	0x74, 0x52, 0x0b, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0x2c,
 	0x0a, 0x0e, 0x45, 0x75, 0x63, 0x6c, 0x69, 0x64, 0x65, 0x61, 0x6e, 0x50, 0x6f, 0x69, 0x6e, 0x74,
 	0x12, 0x0c, 0x0a, 0x01, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x02, 0x52, 0x01, 0x78, 0x12, 0x0c,
 	0x0a, 0x01, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x02, 0x52, 0x01, 0x79, 0x22, 0x46, 0x0a, 0x0a,
@@ -691,13 +1118,28 @@ var file_yolo_grpc_proto_rawDesc = []byte{
 	0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x77, 0x61,
 	0x72, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x77, 0x61, 0x72,
 	0x6e, 0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x32, 0x49, 0x0a, 0x0b, 0x53, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x59, 0x4f, 0x4c, 0x4f, 0x12, 0x3a, 0x0a, 0x0d, 0x53, 0x65, 0x6e,
-	0x64, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x2e, 0x6f, 0x64, 0x61,
-	0x6d, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74,
-	0x69, 0x6f, 0x6e, 0x1a, 0x0e, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x3b, 0x6f, 0x64, 0x61, 0x6d,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x2a, 0x6f, 0x0a, 0x0d, 0x4c, 0x69,
+	0x6e, 0x65, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x18, 0x4c,
+	0x49, 0x4e, 0x45, 0x5f, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x55, 0x4e,
+	0x44, 0x45, 0x46, 0x49, 0x4e, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1e, 0x0a, 0x1a, 0x4c, 0x49, 0x4e,
+	0x45, 0x5f, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x4f, 0x5f, 0x44,
+	0x45, 0x54, 0x45, 0x43, 0x54, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x20, 0x0a, 0x1c, 0x4c, 0x49, 0x4e,
+	0x45, 0x5f, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x46, 0x52, 0x4f, 0x4d,
+	0x5f, 0x44, 0x45, 0x54, 0x45, 0x43, 0x54, 0x4f, 0x52, 0x10, 0x02, 0x2a, 0x80, 0x01, 0x0a, 0x10,
+	0x50, 0x6f, 0x6c, 0x79, 0x67, 0x6f, 0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54, 0x79, 0x70, 0x65,
+	0x12, 0x1b, 0x0a, 0x17, 0x50, 0x4f, 0x4c, 0x59, 0x47, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e,
+	0x54, 0x5f, 0x55, 0x4e, 0x44, 0x45, 0x46, 0x49, 0x4e, 0x45, 0x44, 0x10, 0x00, 0x12, 0x19, 0x0a,
+	0x15, 0x50, 0x4f, 0x4c, 0x59, 0x47, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x45,
+	0x4e, 0x54, 0x45, 0x52, 0x45, 0x44, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x50, 0x4f, 0x4c, 0x59,
+	0x47, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x5f, 0x4c, 0x45, 0x46, 0x54, 0x10, 0x02,
+	0x12, 0x1c, 0x0a, 0x18, 0x50, 0x4f, 0x4c, 0x59, 0x47, 0x4f, 0x4e, 0x5f, 0x45, 0x56, 0x45, 0x4e,
+	0x54, 0x5f, 0x54, 0x52, 0x41, 0x43, 0x4b, 0x5f, 0x4c, 0x4f, 0x53, 0x54, 0x10, 0x03, 0x32, 0x49,
+	0x0a, 0x0b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x59, 0x4f, 0x4c, 0x4f, 0x12, 0x3a, 0x0a,
+	0x0d, 0x53, 0x65, 0x6e, 0x64, 0x44, 0x65, 0x74, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x17,
+	0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x4f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x6e, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x0e, 0x2e, 0x6f, 0x64, 0x61, 0x6d, 0x2e, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x09, 0x5a, 0x07, 0x2e, 0x2f, 0x3b,
+	0x6f, 0x64, 0x61, 0x6d, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -712,33 +1154,47 @@ func file_yolo_grpc_proto_rawDescGZIP() []byte {
 	return file_yolo_grpc_proto_rawDescData
 }
 
-var file_yolo_grpc_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_yolo_grpc_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_yolo_grpc_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_yolo_grpc_proto_goTypes = []interface{}{
-	(*ObjectInformation)(nil), // 0: odam.ObjectInformation
-	(*Detection)(nil),         // 1: odam.Detection
-	(*ClassInfo)(nil),         // 2: odam.ClassInfo
-	(*VirtualLineInfo)(nil),   // 3: odam.VirtualLineInfo
-	(*TrackInfo)(nil),         // 4: odam.TrackInfo
-	(*Point)(nil),             // 5: odam.Point
-	(*EuclideanPoint)(nil),    // 6: odam.EuclideanPoint
-	(*WGS84Point)(nil),        // 7: odam.WGS84Point
-	(*Response)(nil),          // 8: odam.Response
+	(LineDirection)(0),         // 0: odam.LineDirection
+	(PolygonEventType)(0),      // 1: odam.PolygonEventType
+	(*ObjectInformation)(nil),  // 2: odam.ObjectInformation
+	(*Detection)(nil),          // 3: odam.Detection
+	(*ClassInfo)(nil),          // 4: odam.ClassInfo
+	(*VirtualLineInfo)(nil),    // 5: odam.VirtualLineInfo
+	(*VirtualPolygonInfo)(nil), // 6: odam.VirtualPolygonInfo
+	(*SpeedViolationInfo)(nil), // 7: odam.SpeedViolationInfo
+	(*SpeedSample)(nil),        // 8: odam.SpeedSample
+	(*TrackInfo)(nil),          // 9: odam.TrackInfo
+	(*Point)(nil),              // 10: odam.Point
+	(*EuclideanPoint)(nil),     // 11: odam.EuclideanPoint
+	(*WGS84Point)(nil),         // 12: odam.WGS84Point
+	(*Response)(nil),           // 13: odam.Response
 }
 var file_yolo_grpc_proto_depIdxs = []int32{
-	1, // 0: odam.ObjectInformation.detection:type_name -> odam.Detection
-	2, // 1: odam.ObjectInformation.class:type_name -> odam.ClassInfo
-	3, // 2: odam.ObjectInformation.virtual_line:type_name -> odam.VirtualLineInfo
-	4, // 3: odam.ObjectInformation.track_information:type_name -> odam.TrackInfo
-	5, // 4: odam.TrackInfo.points:type_name -> odam.Point
-	6, // 5: odam.Point.euclidean_point:type_name -> odam.EuclideanPoint
-	7, // 6: odam.Point.wgs84_point:type_name -> odam.WGS84Point
-	0, // 7: odam.ServiceYOLO.SendDetection:input_type -> odam.ObjectInformation
-	8, // 8: odam.ServiceYOLO.SendDetection:output_type -> odam.Response
-	8, // [8:9] is the sub-list for method output_type
-	7, // [7:8] is the sub-list for method input_type
-	7, // [7:7] is the sub-list for extension type_name
-	7, // [7:7] is the sub-list for extension extendee
-	0, // [0:7] is the sub-list for field type_name
+	3,  // 0: odam.ObjectInformation.detection:type_name -> odam.Detection
+	4,  // 1: odam.ObjectInformation.class:type_name -> odam.ClassInfo
+	5,  // 2: odam.ObjectInformation.virtual_line:type_name -> odam.VirtualLineInfo
+	9,  // 3: odam.ObjectInformation.track_information:type_name -> odam.TrackInfo
+	6,  // 4: odam.ObjectInformation.virtual_polygon:type_name -> odam.VirtualPolygonInfo
+	7,  // 5: odam.ObjectInformation.speed_violation:type_name -> odam.SpeedViolationInfo
+	0,  // 6: odam.VirtualLineInfo.direction:type_name -> odam.LineDirection
+	11, // 7: odam.VirtualLineInfo.points:type_name -> odam.EuclideanPoint
+	11, // 8: odam.VirtualPolygonInfo.coordinates:type_name -> odam.EuclideanPoint
+	1,  // 9: odam.VirtualPolygonInfo.event_type:type_name -> odam.PolygonEventType
+	8,  // 10: odam.SpeedViolationInfo.samples:type_name -> odam.SpeedSample
+	10, // 11: odam.TrackInfo.points:type_name -> odam.Point
+	11, // 12: odam.Point.euclidean_point:type_name -> odam.EuclideanPoint
+	12, // 13: odam.Point.wgs84_point:type_name -> odam.WGS84Point
+	11, // 14: odam.Point.metric_point:type_name -> odam.EuclideanPoint
+	2,  // 15: odam.ServiceYOLO.SendDetection:input_type -> odam.ObjectInformation
+	13, // 16: odam.ServiceYOLO.SendDetection:output_type -> odam.Response
+	16, // [16:17] is the sub-list for method output_type
+	15, // [15:16] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_yolo_grpc_proto_init() }
@@ -796,7 +1252,7 @@ func file_yolo_grpc_proto_init() {
 			}
 		}
 		file_yolo_grpc_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*TrackInfo); i {
+			switch v := v.(*VirtualPolygonInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -808,7 +1264,7 @@ func file_yolo_grpc_proto_init() {
 			}
 		}
 		file_yolo_grpc_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Point); i {
+			switch v := v.(*SpeedViolationInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -820,7 +1276,7 @@ func file_yolo_grpc_proto_init() {
 			}
 		}
 		file_yolo_grpc_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EuclideanPoint); i {
+			switch v := v.(*SpeedSample); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -832,7 +1288,7 @@ func file_yolo_grpc_proto_init() {
 			}
 		}
 		file_yolo_grpc_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WGS84Point); i {
+			switch v := v.(*TrackInfo); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -844,6 +1300,42 @@ func file_yolo_grpc_proto_init() {
 			}
 		}
 		file_yolo_grpc_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Point); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yolo_grpc_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EuclideanPoint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yolo_grpc_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WGS84Point); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_yolo_grpc_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Response); i {
 			case 0:
 				return &v.state
@@ -861,13 +1353,14 @@ func file_yolo_grpc_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_yolo_grpc_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   9,
+			NumEnums:      2,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_yolo_grpc_proto_goTypes,
 		DependencyIndexes: file_yolo_grpc_proto_depIdxs,
+		EnumInfos:         file_yolo_grpc_proto_enumTypes,
 		MessageInfos:      file_yolo_grpc_proto_msgTypes,
 	}.Build()
 	File_yolo_grpc_proto = out.File